@@ -0,0 +1,135 @@
+package winfileask
+
+import "errors"
+
+// ErrCanceled is returned by a Backend when the user closes the dialog
+// without making a selection.
+var ErrCanceled = errors.New("dialog canceled")
+
+// Mode is the kind of dialog a Backend shows.
+type Mode int
+
+const (
+	// ModeOpen asks the user for one or more existing files.
+	ModeOpen Mode = iota
+	// ModeSave asks the user for a file name to save to.
+	ModeSave
+	// ModeFolder asks the user for a directory.
+	ModeFolder
+)
+
+// The default flags used by GetOpenFileName and GetSaveFileName. They are a
+// good starting point for the Flags member of Options.
+const (
+	OpenFlags = FileMustExist | HideReadOnly | PathMustExist | NoChangeDir
+	SaveFlags = HideReadOnly | PathMustExist | NoChangeDir | OverwritePrompt
+)
+
+// Options describes a dialog independently of the Backend that shows it.
+type Options struct {
+	// Mode selects an Open, Save As or folder dialog.
+	Mode Mode
+	// Title is placed in the title bar of the dialog. If it is empty, the
+	// backend uses its default title.
+	Title string
+	// Filter is the list of file types offered to the user. It is ignored in
+	// ModeFolder.
+	Filter FileFilter
+	// InitialDir is the directory the dialog starts in.
+	InitialDir string
+	// InitialFileName is the file name used to initialize the file name
+	// field, usually in ModeSave.
+	InitialFileName string
+	// UniqueFileName replaces InitialFileName in ModeSave with a name that
+	// does not exist in InitialDir yet; see SuggestSaveName.
+	UniqueFileName bool
+	// DialogID identifies the dialog within the application, for example
+	// "export-report". It is not shown, but lets scripted answers target
+	// the dialog; see Replay.
+	DialogID string
+	// Flags is a combination of the package flags. Backends other than the
+	// native one honor the subset they can express, such as
+	// AllowMultiSelect, FileMustExist, PathMustExist, OverwritePrompt and
+	// ForceShowHidden.
+	Flags uint32
+	// ResolveShortcuts replaces opened shortcut (.lnk) files with their
+	// targets, for the backends and flags, such as NoDereferenceLinks, that
+	// return the shortcut itself.
+	ResolveShortcuts bool
+	// AllowedRoots, if not empty, confines the selection to these
+	// directories and everything below them. Dialogs start in the first
+	// root if InitialDir, or the current directory if it is empty, is
+	// outside them. The TUI and Prompt backends do not let the user leave
+	// the roots, and the native dialogs refuse a selection outside them
+	// before they close, which makes the Open and Save As dialog boxes
+	// Explorer-style. With the other backends, a selection outside them
+	// fails with a *RootError.
+	AllowedRoots []string
+	// Extensions restricts the extensions of opened and saved files. The
+	// TUI and Prompt backends ask again on a rejected file; with the others
+	// the selection fails with an *ExtensionError.
+	Extensions ExtensionPolicy
+	// Links selects what happens to selected paths that go through
+	// symbolic links or junctions.
+	Links LinkPolicy
+	// ContentCheck compares the content of opened files with the
+	// ContentRules of the filter they were chosen with.
+	ContentCheck ContentCheck
+	// ContentRules describes the content of the files each filter is for,
+	// keyed by the index of the filter in Filter. A file chosen with a
+	// filter without a rule, such as the "All supported files" entry of
+	// FilterBuilder, is checked against the rules of the filters whose
+	// patterns match its name. Unless ContentCheck is ContentIgnore, the
+	// rules are checked with ContentRule.Validate before an Open dialog is
+	// shown.
+	ContentRules map[int]ContentRule
+	// Validate, if not nil, is called with the selection after the other
+	// checks. An error it returns is returned instead of the Result.
+	Validate func(res *Result) error `json:"-"`
+}
+
+// title returns Title, or the default title for the mode if it is empty.
+func (o Options) title() string {
+	if o.Title != "" {
+		return o.Title
+	}
+	switch o.Mode {
+	case ModeSave:
+		return "Save As"
+	case ModeFolder:
+		return "Select Folder"
+	}
+	return "Open"
+}
+
+// Result holds the selection the user made in a dialog.
+type Result struct {
+	// Paths holds the selected paths. It contains exactly one path unless
+	// the AllowMultiSelect flag was set.
+	Paths []string
+	// FilterIndex is the zero-based index into Options.Filter of the filter
+	// that was selected when the dialog closed, or -1 if the backend cannot
+	// tell.
+	FilterIndex int
+	// RawPaths holds the paths as the backend returned them when
+	// ResolveShortcuts or LinkResolve replaced any of Paths, and is nil
+	// otherwise.
+	RawPaths []string
+	// Mismatched holds the paths whose content did not match their filter
+	// when Options.ContentCheck is ContentFlag.
+	Mismatched []string
+}
+
+// Path returns the first selected path, or "" if there is none.
+func (r *Result) Path() string {
+	if len(r.Paths) == 0 {
+		return ""
+	}
+	return r.Paths[0]
+}
+
+// Backend shows file dialogs. Implementations return ErrCanceled when the
+// user dismisses the dialog.
+type Backend interface {
+	Show(opts Options) (*Result, error)
+}
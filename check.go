@@ -0,0 +1,73 @@
+package winfileask
+
+// checkResult applies the checks that opts asks for to a selection made with
+// any Backend, and finally calls opts.Validate.
+func checkResult(opts Options, res *Result) error {
+	// Work on a copy, since the backend may hold on to its slice.
+	raw := res.Paths
+	res.Paths = append([]string(nil), raw...)
+	changed := false
+	if opts.Mode == ModeOpen && opts.ResolveShortcuts {
+		for i, path := range res.Paths {
+			if !isShortcut(path) {
+				continue
+			}
+			target, err := resolveShortcut(path)
+			if err != nil {
+				return err
+			}
+			res.Paths[i] = target
+			changed = true
+		}
+	}
+	if opts.Links != LinkKeep {
+		for i, path := range res.Paths {
+			target, linked, err := resolveLinks(path)
+			switch {
+			case err != nil:
+				return err
+			case linked && opts.Links == LinkReject:
+				return &LinkError{Path: path, Target: target}
+			case linked:
+				res.Paths[i] = target
+				changed = true
+			}
+		}
+	}
+	if changed {
+		res.RawPaths = raw
+	}
+	for _, path := range res.Paths {
+		if !opts.allowed(path) {
+			return &RootError{Path: path}
+		}
+	}
+	if opts.Mode != ModeFolder && !opts.Extensions.isZero() {
+		for _, path := range res.Paths {
+			if err := opts.Extensions.Check(path); err != nil {
+				return err
+			}
+		}
+	}
+	if opts.Mode == ModeOpen && opts.ContentCheck != ContentIgnore {
+		for _, path := range res.Paths {
+			err := checkContent(opts, res.FilterIndex, path)
+			if _, ok := err.(*ContentError); ok && opts.ContentCheck == ContentFlag {
+				res.Mismatched = append(res.Mismatched, path)
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+	if opts.Mode == ModeSave {
+		for _, path := range res.Paths {
+			if err := ValidateFileName(baseName(path)); err != nil {
+				return err
+			}
+		}
+	}
+	if opts.Validate != nil {
+		return opts.Validate(res)
+	}
+	return nil
+}
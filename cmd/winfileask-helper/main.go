@@ -0,0 +1,22 @@
+//go:build windows
+
+// Command winfileask-helper shows a native file dialog on behalf of the WSL
+// backend. It reads the dialog options as JSON from standard input and writes
+// the selection as JSON to standard output.
+//
+// Build it with GOOS=windows and point WINFILEASK_HELPER at the executable.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kroppt/winfileask"
+)
+
+func main() {
+	if err := winfileask.ServeHelper(os.Stdin, os.Stdout, winfileask.Native{}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,282 @@
+package winfileask
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// comdlg is the part of comdlg32.dll used by the Open and Save As dialogs.
+// It is an interface so that everything around the calls, from building the
+// TagOFNA to decoding the result, also runs where comdlg32.dll does not
+// exist.
+type comdlg interface {
+	GetOpenFileName(ofn *TagOFNA) bool
+	GetSaveFileName(ofn *TagOFNA) bool
+	CommDlgExtendedError() uint32
+}
+
+// The extended error codes returned by CommDlgExtendedError.
+const (
+	CDErrDialogFailure   DialogError = 0xFFFF
+	CDErrStructSize      DialogError = 0x0001
+	CDErrInitialization  DialogError = 0x0002
+	CDErrNoTemplate      DialogError = 0x0003
+	CDErrNoHInstance     DialogError = 0x0004
+	CDErrLoadStrFailure  DialogError = 0x0005
+	CDErrFindResFailure  DialogError = 0x0006
+	CDErrLoadResFailure  DialogError = 0x0007
+	CDErrLockResFailure  DialogError = 0x0008
+	CDErrMemAllocFailure DialogError = 0x0009
+	CDErrMemLockFailure  DialogError = 0x000A
+	CDErrNoHook          DialogError = 0x000B
+	CDErrRegisterMsgFail DialogError = 0x000C
+	FNErrSubclassFailure DialogError = 0x3001
+	FNErrInvalidFileName DialogError = 0x3002
+	FNErrBufferTooSmall  DialogError = 0x3003
+)
+
+// DialogError is an extended error code reported by CommDlgExtendedError
+// when a common dialog box fails.
+type DialogError uint32
+
+var dialogErrors = map[DialogError]string{
+	CDErrDialogFailure:   "the dialog box could not be created",
+	CDErrStructSize:      "invalid lStructSize",
+	CDErrInitialization:  "initialization failed",
+	CDErrNoTemplate:      "no template was specified",
+	CDErrNoHInstance:     "no instance handle was specified",
+	CDErrLoadStrFailure:  "failed to load a string",
+	CDErrFindResFailure:  "failed to find a resource",
+	CDErrLoadResFailure:  "failed to load a resource",
+	CDErrLockResFailure:  "failed to lock a resource",
+	CDErrMemAllocFailure: "failed to allocate memory",
+	CDErrMemLockFailure:  "failed to lock memory",
+	CDErrNoHook:          "no hook procedure was specified",
+	CDErrRegisterMsgFail: "failed to register a window message",
+	FNErrSubclassFailure: "failed to subclass a list box",
+	FNErrInvalidFileName: "invalid file name",
+	FNErrBufferTooSmall:  "the file name buffer is too small",
+}
+
+func (e DialogError) Error() string {
+	if s, ok := dialogErrors[e]; ok {
+		return "common dialog: " + s
+	}
+	return fmt.Sprintf("common dialog error 0x%04x", uint32(e))
+}
+
+const (
+	// nativeFileSize is the size, in characters, of the lpstrFile buffer for
+	// a single selection. nativeMultiFileSize is used with AllowMultiSelect.
+	nativeFileSize      = 1024
+	nativeMultiFileSize = 65536
+)
+
+// showFileDialog shows an Open or Save As dialog box through dlg.
+func showFileDialog(dlg comdlg, owner unsafe.Pointer, opts Options) (*Result, error) {
+	// CommDlgExtendedError reports the last error of the calling thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	flags := opts.Flags
+	size := nativeFileSize
+	if flags&AllowMultiSelect != 0 {
+		// NoLongNames only affects old-style dialog boxes, so it is taken as
+		// a request for one.
+		if flags&NoLongNames == 0 {
+			flags |= Explorer
+		}
+		size = nativeMultiFileSize
+	}
+	if len(opts.AllowedRoots) != 0 {
+		// The hook refuses a selection outside the roots before the dialog
+		// box closes. CDN_FILEOK is only sent to Explorer-style hooks.
+		flags |= EnableHook | Explorer
+	}
+	var ofn *TagOFNA
+	var err error
+	if ofn, err = NewTagOFNA(owner, opts.Title, opts.Filter, opts.InitialDir, flags); err != nil {
+		return nil, err
+	}
+	if opts.Title == "" {
+		ofn.LpstrTitle = nil
+	}
+	if flags&EnableHook != 0 {
+		ofn.LpfnHook = fileOKHook
+		ofn.LCustData = addHookData(opts.rootMessage)
+		defer removeHookData(ofn.LCustData)
+	}
+	buf := make([]uint16, size)
+	var name []uint16
+	if name, err = utf16FromString(opts.InitialFileName); err != nil {
+		return nil, err
+	}
+	if len(name) > len(buf) {
+		return nil, fmt.Errorf("initial file name is too long")
+	}
+	copy(buf, name)
+	ofn.LpstrFile = &buf[0]
+	ofn.NMaxFile = uint32(size)
+	var ok bool
+	if opts.Mode == ModeSave {
+		ok = dlg.GetSaveFileName(ofn)
+	} else {
+		ok = dlg.GetOpenFileName(ofn)
+	}
+	if !ok {
+		if code := dlg.CommDlgExtendedError(); code != 0 {
+			return nil, DialogError(code)
+		}
+		return nil, ErrCanceled
+	}
+	// Old-style dialog boxes may return 8.3 short names.
+	paths := splitFileBuffer(buf, ofn.NFileOffset)
+	for i, path := range paths {
+		paths[i] = longPathName(path)
+	}
+	return &Result{
+		Paths:       paths,
+		FilterIndex: int(ofn.NFilterIndex) - 1,
+	}, nil
+}
+
+// hookData holds the values that hook procedures of the dialogs being shown
+// need, keyed by the lCustData or lParam value the procedures receive, since
+// Go pointers cannot be passed through them.
+var hookData = struct {
+	sync.Mutex
+	next uintptr
+	m    map[uintptr]any
+}{m: map[uintptr]any{}}
+
+// addHookData stores v and returns its key, which is never 0.
+func addHookData(v any) uintptr {
+	hookData.Lock()
+	defer hookData.Unlock()
+	hookData.next++
+	hookData.m[hookData.next] = v
+	return hookData.next
+}
+
+// lookupHookData returns the value stored under key, or nil.
+func lookupHookData(key uintptr) any {
+	hookData.Lock()
+	defer hookData.Unlock()
+	return hookData.m[key]
+}
+
+func removeHookData(key uintptr) {
+	hookData.Lock()
+	defer hookData.Unlock()
+	delete(hookData.m, key)
+}
+
+// fileOKMessage returns the message to refuse the selection in ofn with, or
+// "" to accept it, for the hook procedure of showFileDialog.
+func fileOKMessage(ofn *TagOFNA) string {
+	check, ok := lookupHookData(ofn.LCustData).(func(paths []string) string)
+	if !ok || ofn.LpstrFile == nil {
+		return ""
+	}
+	paths := splitFileBuffer(unsafe.Slice(ofn.LpstrFile, ofn.NMaxFile), ofn.NFileOffset)
+	for i, path := range paths {
+		paths[i] = longPathName(path)
+	}
+	return check(paths)
+}
+
+// getFileName shows the dialog of GetOpenFileName or GetSaveFileName through
+// dlg. Unlike showFileDialog, it keeps the behavior those functions always
+// had: the title is used as given, even if it is empty, and any failure of
+// the dialog box is reported as no selection rather than as a DialogError.
+func getFileName(dlg comdlg, owner unsafe.Pointer, mode Mode, title string, filter FileFilter, initialDir string) (string, bool, error) {
+	flags := OpenFlags
+	if mode == ModeSave {
+		flags = SaveFlags
+	}
+	var ofn *TagOFNA
+	var err error
+	if ofn, err = NewTagOFNA(owner, title, filter, initialDir, flags); err != nil {
+		return "", false, err
+	}
+	buf := make([]uint16, nativeFileSize)
+	ofn.LpstrFile = &buf[0]
+	ofn.NMaxFile = nativeFileSize
+	var ok bool
+	if mode == ModeSave {
+		ok = dlg.GetSaveFileName(ofn)
+	} else {
+		ok = dlg.GetOpenFileName(ofn)
+	}
+	if !ok {
+		return "", false, nil
+	}
+	return utf16ToString(buf), true, nil
+}
+
+// splitFileBuffer decodes the lpstrFile buffer. A multiple selection is
+// returned as the directory followed by the file names, separated by NULs in
+// Explorer-style dialog boxes and by spaces in old-style ones.
+func splitFileBuffer(buf []uint16, offset uint16) []string {
+	if offset == 0 || int(offset) >= len(buf) || (buf[offset-1] != 0 && buf[offset-1] != ' ') {
+		return []string{utf16ToString(buf)}
+	}
+	var dir string
+	var names []string
+	if buf[offset-1] == ' ' {
+		dir = utf16ToString(buf[:offset-1])
+		names = strings.Fields(utf16ToString(buf[offset:]))
+	} else {
+		dir = utf16ToString(buf[:offset])
+		for i := int(offset); i < len(buf) && buf[i] != 0; {
+			j := i
+			for j < len(buf) && buf[j] != 0 {
+				j++
+			}
+			names = append(names, utf16ToString(buf[i:j]))
+			i = j + 1
+		}
+	}
+	if !strings.HasSuffix(dir, `\`) {
+		dir += `\`
+	}
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = dir + name
+	}
+	return paths
+}
+
+// utf16FromString returns the NUL terminated UTF-16 encoding of s. Like
+// syscall.UTF16FromString, it fails with EINVAL if s contains a NUL.
+func utf16FromString(s string) ([]uint16, error) {
+	if strings.IndexByte(s, 0) != -1 {
+		return nil, syscall.EINVAL
+	}
+	return append(utf16.Encode([]rune(s)), 0), nil
+}
+
+// utf16PtrFromString is like utf16FromString but returns a pointer to the
+// first character.
+func utf16PtrFromString(s string) (*uint16, error) {
+	a, err := utf16FromString(s)
+	if err != nil {
+		return nil, err
+	}
+	return &a[0], nil
+}
+
+// utf16ToString decodes s up to its first NUL.
+func utf16ToString(s []uint16) string {
+	for i, c := range s {
+		if c == 0 {
+			s = s[:i]
+			break
+		}
+	}
+	return string(utf16.Decode(s))
+}
@@ -0,0 +1,290 @@
+package winfileask
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// testFilter is a small filter shared by the tests.
+var testFilter = FileFilter{
+	{Name: "Text", Pattern: "*.txt"},
+	{Name: "Images", Pattern: "*.png;*.jpg"},
+}
+
+// fakeComdlg stands in for comdlg32.dll. It records the TagOFNA it is given
+// and answers by writing file into the lpstrFile buffer, or by failing with
+// code.
+type fakeComdlg struct {
+	// file is written to lpstrFile, including any NULs it contains.
+	file   string
+	offset uint16
+	filter uint32
+	code   uint32
+	cancel bool
+
+	calls    int
+	save     bool
+	flags    uint32
+	maxFile  uint32
+	title    string
+	hasTitle bool
+	filters  FileFilter
+	// rawFilter is lpstrFilter up to and including its final NULs.
+	rawFilter string
+	initial   string
+	dir       string
+	// refused is the message the hook procedure refused file with. The
+	// user then cancels the dialog box.
+	refused string
+}
+
+func (f *fakeComdlg) GetOpenFileName(ofn *TagOFNA) bool {
+	return f.answer(ofn, false)
+}
+
+func (f *fakeComdlg) GetSaveFileName(ofn *TagOFNA) bool {
+	return f.answer(ofn, true)
+}
+
+func (f *fakeComdlg) CommDlgExtendedError() uint32 {
+	return f.code
+}
+
+func (f *fakeComdlg) answer(ofn *TagOFNA, save bool) bool {
+	f.calls++
+	f.save = save
+	f.flags = ofn.Flags
+	f.maxFile = ofn.NMaxFile
+	f.hasTitle = ofn.LpstrTitle != nil
+	if f.hasTitle {
+		f.title = ptrToString(ofn.LpstrTitle)
+	}
+	if ofn.LpstrInitialDir != nil {
+		f.dir = ptrToString(ofn.LpstrInitialDir)
+	}
+	var err error
+	if f.filters, err = FileFilterFromRaw(ofn.LpstrFilter); err != nil {
+		panic(err)
+	}
+	if p := ofn.LpstrFilter; p != nil {
+		var raw []uint16
+		for prev := uint16(1); prev != 0 || *p != 0; p = (*uint16)(unsafe.Add(unsafe.Pointer(p), 2)) {
+			prev = *p
+			raw = append(raw, prev)
+		}
+		f.rawFilter = string(utf16.Decode(append(raw, 0)))
+	}
+	buf := unsafe.Slice(ofn.LpstrFile, ofn.NMaxFile)
+	f.initial = utf16ToString(buf)
+	if f.cancel || f.code != 0 {
+		return false
+	}
+	copy(buf, append(utf16.Encode([]rune(f.file)), 0))
+	ofn.NFileOffset = f.offset
+	ofn.NFilterIndex = f.filter
+	if ofn.Flags&EnableHook != 0 {
+		f.refused = fileOKMessage(ofn)
+		return f.refused == ""
+	}
+	return true
+}
+
+func TestGetFileName(t *testing.T) {
+	dlg := &fakeComdlg{file: `C:\docs\a.txt`, offset: 8}
+	path, ok, err := getFileName(dlg, nil, ModeOpen, "", testFilter, `C:\docs`)
+	if path != `C:\docs\a.txt` || !ok || err != nil {
+		t.Errorf("open = %q, %v, %v; want the path, true, nil", path, ok, err)
+	}
+	if dlg.save || dlg.flags != OpenFlags || dlg.maxFile != nativeFileSize {
+		t.Errorf("open dialog: save %v, flags %#x, nMaxFile %d", dlg.save, dlg.flags, dlg.maxFile)
+	}
+	// An empty title is passed on as is rather than as the default title.
+	if !dlg.hasTitle || dlg.title != "" {
+		t.Errorf("title = %q (set %v), want an empty title", dlg.title, dlg.hasTitle)
+	}
+	if len(dlg.filters) != len(testFilter) || dlg.dir != `C:\docs` {
+		t.Errorf("filter %v, initial dir %q", dlg.filters, dlg.dir)
+	}
+
+	dlg = &fakeComdlg{file: `C:\out.txt`, offset: 3}
+	if path, ok, err = getFileName(dlg, nil, ModeSave, "Save log", nil, ""); path != `C:\out.txt` || !ok || err != nil {
+		t.Errorf("save = %q, %v, %v; want the path, true, nil", path, ok, err)
+	}
+	if !dlg.save || dlg.flags != SaveFlags || dlg.title != "Save log" {
+		t.Errorf("save dialog: save %v, flags %#x, title %q", dlg.save, dlg.flags, dlg.title)
+	}
+
+	for _, dlg := range []*fakeComdlg{{cancel: true}, {code: uint32(FNErrBufferTooSmall)}} {
+		if path, ok, err = getFileName(dlg, nil, ModeOpen, "", nil, ""); path != "" || ok || err != nil {
+			t.Errorf("failure %#x = %q, %v, %v; want \"\", false, nil", dlg.code, path, ok, err)
+		}
+	}
+
+	dlg = &fakeComdlg{}
+	if _, _, err = getFileName(dlg, nil, ModeOpen, "", FileFilter{{Name: "", Pattern: "*"}}, ""); err == nil || dlg.calls != 0 {
+		t.Errorf("invalid filter: err %v after %d calls, want an error and no dialog", err, dlg.calls)
+	}
+}
+
+func TestSplitFileBuffer(t *testing.T) {
+	tests := []struct {
+		name   string
+		buf    string
+		offset uint16
+		want   []string
+	}{
+		{"single", `C:\docs\a.txt`, 8, []string{`C:\docs\a.txt`}},
+		{"single in a root", `C:\a.txt`, 3, []string{`C:\a.txt`}},
+		{"no offset", `C:\docs\a.txt`, 0, []string{`C:\docs\a.txt`}},
+		{"offset past the buffer", `C:\a`, 40, []string{`C:\a`}},
+		{"explorer", "C:\\docs\x00a.txt\x00b c.txt\x00", 8, []string{`C:\docs\a.txt`, `C:\docs\b c.txt`}},
+		{"explorer in a root", "D:\\\x00a.txt\x00b.txt\x00", 4, []string{`D:\a.txt`, `D:\b.txt`}},
+		{"explorer unc", "\\\\srv\\share\x00a\x00b\x00", 12, []string{`\\srv\share\a`, `\\srv\share\b`}},
+		{"old-style", `C:\DOCS A.TXT B.TXT`, 8, []string{`C:\DOCS\A.TXT`, `C:\DOCS\B.TXT`}},
+		{"old-style short names", `C:\PROGRA~1\MYAPP~1 REPORT~1.TXT DATA~2.CSV`, 20, []string{`C:\PROGRA~1\MYAPP~1\REPORT~1.TXT`, `C:\PROGRA~1\MYAPP~1\DATA~2.CSV`}},
+		{"old-style in a root", `C:\ A.TXT B.TXT`, 4, []string{`C:\A.TXT`, `C:\B.TXT`}},
+		{"old-style extra spaces", `C:\DOCS A.TXT  B.TXT `, 8, []string{`C:\DOCS\A.TXT`, `C:\DOCS\B.TXT`}},
+		{"old-style unc", `\\SRV\SHARE A.TXT`, 12, []string{`\\SRV\SHARE\A.TXT`}},
+		{"unicode", "C:\\Zoë\x00ä.txt\x00✓.txt\x00", 7, []string{`C:\Zoë\ä.txt`, `C:\Zoë\✓.txt`}},
+	}
+	for _, tt := range tests {
+		buf := append(utf16.Encode([]rune(tt.buf)), 0)
+		if got := splitFileBuffer(buf, tt.offset); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: splitFileBuffer(%q, %d) = %q, want %q", tt.name, tt.buf, tt.offset, got, tt.want)
+		}
+	}
+}
+
+// ptrToString decodes the NUL terminated UTF-16 string at p.
+func ptrToString(p *uint16) string {
+	var s []uint16
+	for ; *p != 0; p = (*uint16)(unsafe.Add(unsafe.Pointer(p), 2)) {
+		s = append(s, *p)
+	}
+	return string(utf16.Decode(s))
+}
+
+func TestShowFileDialog(t *testing.T) {
+	const rawFilter = "Text\x00*.txt\x00Images\x00*.png;*.jpg\x00\x00"
+	tests := []struct {
+		name    string
+		opts    Options
+		dlg     fakeComdlg
+		flags   uint32
+		maxFile uint32
+		paths   []string
+		index   int
+	}{
+		{
+			"open",
+			Options{Title: "Open data", Filter: testFilter, InitialDir: `C:\docs`, Flags: OpenFlags},
+			fakeComdlg{file: `C:\docs\a.txt`, offset: 8, filter: 2},
+			OpenFlags, nativeFileSize, []string{`C:\docs\a.txt`}, 1,
+		},
+		{
+			"open in a root",
+			Options{Filter: testFilter, Flags: OpenFlags},
+			fakeComdlg{file: `C:\a.txt`, offset: 3, filter: 1},
+			OpenFlags, nativeFileSize, []string{`C:\a.txt`}, 0,
+		},
+		{
+			"explorer multiple",
+			Options{Filter: testFilter, Flags: OpenFlags | AllowMultiSelect},
+			fakeComdlg{file: "C:\\docs\x00a.txt\x00b c.txt\x00", offset: 8, filter: 1},
+			OpenFlags | AllowMultiSelect | Explorer, nativeMultiFileSize, []string{`C:\docs\a.txt`, `C:\docs\b c.txt`}, 0,
+		},
+		{
+			"explorer multiple in a root",
+			Options{Filter: testFilter, Flags: OpenFlags | AllowMultiSelect},
+			fakeComdlg{file: "D:\\\x00a.txt\x00b.txt\x00", offset: 4},
+			OpenFlags | AllowMultiSelect | Explorer, nativeMultiFileSize, []string{`D:\a.txt`, `D:\b.txt`}, -1,
+		},
+		{
+			"explorer multiple with one file",
+			Options{Filter: testFilter, Flags: OpenFlags | AllowMultiSelect},
+			fakeComdlg{file: `C:\docs\a.txt`, offset: 8, filter: 2},
+			OpenFlags | AllowMultiSelect | Explorer, nativeMultiFileSize, []string{`C:\docs\a.txt`}, 1,
+		},
+		{
+			"old-style multiple",
+			Options{Filter: testFilter, Flags: OpenFlags | AllowMultiSelect | NoLongNames},
+			fakeComdlg{file: `C:\DOCS A.TXT REPORT~1.TXT`, offset: 8, filter: 1},
+			OpenFlags | AllowMultiSelect | NoLongNames, nativeMultiFileSize, []string{`C:\DOCS\A.TXT`, `C:\DOCS\REPORT~1.TXT`}, 0,
+		},
+		{
+			"old-style multiple with one file",
+			Options{Filter: testFilter, Flags: OpenFlags | AllowMultiSelect | NoLongNames},
+			fakeComdlg{file: `C:\DOCS\A.TXT`, offset: 8, filter: 1},
+			OpenFlags | AllowMultiSelect | NoLongNames, nativeMultiFileSize, []string{`C:\DOCS\A.TXT`}, 0,
+		},
+		{
+			"save",
+			Options{Mode: ModeSave, Filter: testFilter, InitialFileName: "report.txt", Flags: SaveFlags},
+			fakeComdlg{file: `C:\out\report.txt`, offset: 7, filter: 1},
+			SaveFlags, nativeFileSize, []string{`C:\out\report.txt`}, 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dlg := tt.dlg
+			res, err := showFileDialog(&dlg, nil, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res.Paths, tt.paths) || res.FilterIndex != tt.index {
+				t.Errorf("result = %q, %d; want %q, %d", res.Paths, res.FilterIndex, tt.paths, tt.index)
+			}
+			if dlg.calls != 1 || dlg.save != (tt.opts.Mode == ModeSave) {
+				t.Errorf("%d calls, save dialog %v", dlg.calls, dlg.save)
+			}
+			if dlg.flags != tt.flags {
+				t.Errorf("flags = %#x, want %#x", dlg.flags, tt.flags)
+			}
+			if dlg.maxFile != tt.maxFile {
+				t.Errorf("nMaxFile = %d, want %d", dlg.maxFile, tt.maxFile)
+			}
+			if dlg.rawFilter != rawFilter {
+				t.Errorf("lpstrFilter = %q, want %q", dlg.rawFilter, rawFilter)
+			}
+			if dlg.hasTitle != (tt.opts.Title != "") || dlg.title != tt.opts.Title {
+				t.Errorf("title = %q (set %v), want %q", dlg.title, dlg.hasTitle, tt.opts.Title)
+			}
+			if dlg.initial != tt.opts.InitialFileName || dlg.dir != tt.opts.InitialDir {
+				t.Errorf("initial file %q in %q, want %q in %q", dlg.initial, dlg.dir, tt.opts.InitialFileName, tt.opts.InitialDir)
+			}
+		})
+	}
+}
+
+func TestShowFileDialogErrors(t *testing.T) {
+	if _, err := showFileDialog(&fakeComdlg{cancel: true}, nil, Options{}); err != ErrCanceled {
+		t.Errorf("canceled: err = %v, want ErrCanceled", err)
+	}
+	for code, text := range dialogErrors {
+		_, err := showFileDialog(&fakeComdlg{code: uint32(code)}, nil, Options{})
+		if err != code {
+			t.Errorf("code %#x: err = %v, want %v", uint32(code), err, code)
+		} else if err.Error() != "common dialog: "+text {
+			t.Errorf("code %#x: message %q", uint32(code), err.Error())
+		}
+	}
+	_, err := showFileDialog(&fakeComdlg{code: 0x4242}, nil, Options{Mode: ModeSave})
+	if err != DialogError(0x4242) || err.Error() != "common dialog error 0x4242" {
+		t.Errorf("unknown code: err = %v", err)
+	}
+
+	for i, opts := range []Options{
+		{Filter: FileFilter{{Name: "Text", Pattern: ""}}},
+		{InitialFileName: "a\x00b"},
+		{InitialFileName: strings.Repeat("x", nativeFileSize+1)},
+		{Title: "a\x00b"},
+	} {
+		dlg := &fakeComdlg{}
+		if _, err = showFileDialog(dlg, nil, opts); err == nil || dlg.calls != 0 {
+			t.Errorf("options %d: err %v after %d calls, want an error and no dialog", i, err, dlg.calls)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package winfileask
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Signature is a sequence of bytes, often called a magic number, found at a
+// fixed offset in every file of a type.
+type Signature struct {
+	Offset int
+	Magic  []byte
+}
+
+// ContentCheck selects what happens to opened files whose content does not
+// match the filter they were chosen with.
+type ContentCheck int
+
+const (
+	// ContentIgnore does not look at the content of files.
+	ContentIgnore ContentCheck = iota
+	// ContentFlag lists the files that do not match in Result.Mismatched.
+	ContentFlag
+	// ContentReject fails with a *ContentError if any file does not match.
+	ContentReject
+)
+
+// sniffLen is the number of bytes http.DetectContentType looks at.
+const sniffLen = 512
+
+// ContentError reports a file whose content does not match its filter.
+type ContentError struct {
+	Path string
+	// ContentType is the type of the content according to
+	// http.DetectContentType.
+	ContentType string
+}
+
+func (e *ContentError) Error() string {
+	return fmt.Sprintf("%s: content of type %s does not match the selected file type", e.Path, e.ContentType)
+}
+
+// ContentRule describes the content of the files a filter is for. Rules are
+// given in Options.ContentRules and used by the ContentCheck option.
+type ContentRule struct {
+	// Signatures, if not empty, are the signatures one of which every file
+	// must have.
+	Signatures []Signature
+	// ContentType is used when there are no Signatures. It is a type such as
+	// "image/png", or a media range such as "image/*", that
+	// http.DetectContentType must report for the file. That function knows
+	// only a few types, listed in DetectableTypes; text/csv, application/json
+	// and most document formats are reported as text/plain or
+	// application/octet-stream, and need Signatures instead.
+	ContentType string
+}
+
+// DetectableTypes are the types http.DetectContentType can report, and so
+// the only ones a ContentRule.ContentType can usefully name.
+var DetectableTypes = []string{
+	"application/octet-stream", "application/ogg", "application/pdf",
+	"application/postscript", "application/vnd.ms-fontobject",
+	"application/wasm", "application/x-gzip", "application/x-rar-compressed",
+	"application/zip", "audio/aiff", "audio/midi", "audio/mpeg", "audio/wave",
+	"font/collection", "font/otf", "font/ttf", "font/woff", "font/woff2",
+	"image/bmp", "image/gif", "image/jpeg", "image/png",
+	"image/vnd.microsoft.icon", "image/webp", "image/x-icon", "text/html",
+	"text/plain", "text/xml", "video/avi", "video/mp4", "video/webm",
+}
+
+// ErrUndetectableType is reported, wrapped in a *FilterError, for a
+// ContentRule whose ContentType http.DetectContentType never reports.
+var ErrUndetectableType = errors.New("content type cannot be detected")
+
+// Validate checks that r.ContentType, if set, is one of DetectableTypes or a
+// media range covering at least one of them. A rule with Signatures does not
+// use its ContentType and is always valid.
+func (r ContentRule) Validate() error {
+	if len(r.Signatures) > 0 || r.ContentType == "" {
+		return nil
+	}
+	want := baseType(r.ContentType)
+	major, isRange := mediaRange(want)
+	for _, t := range DetectableTypes {
+		if t == want || (isRange && strings.HasPrefix(t, major+"/")) {
+			return nil
+		}
+	}
+	return ErrUndetectableType
+}
+
+// Match reports whether data, the start of a file, matches r. If r has
+// Signatures, one of them must be found in data. Otherwise, if r has a
+// ContentType such as "image/png" or "image/*", http.DetectContentType must
+// report that type for data. The zero ContentRule matches everything.
+func (r ContentRule) Match(data []byte) bool {
+	if len(r.Signatures) > 0 {
+		for _, s := range r.Signatures {
+			if s.Offset >= 0 && s.Offset+len(s.Magic) <= len(data) && bytes.Equal(data[s.Offset:s.Offset+len(s.Magic)], s.Magic) {
+				return true
+			}
+		}
+		return false
+	}
+	if r.ContentType == "" {
+		return true
+	}
+	detected := baseType(http.DetectContentType(data))
+	want := baseType(r.ContentType)
+	if major, ok := mediaRange(want); ok {
+		return strings.HasPrefix(detected, major+"/")
+	}
+	return detected == want
+}
+
+// isZero reports whether r has nothing to check.
+func (r ContentRule) isZero() bool {
+	return len(r.Signatures) == 0 && r.ContentType == ""
+}
+
+// validateContentRules checks every rule of opts, reporting the first
+// problem as a *FilterError.
+func validateContentRules(opts Options) error {
+	indexes := make([]int, 0, len(opts.ContentRules))
+	for i := range opts.ContentRules {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for _, i := range indexes {
+		if err := opts.ContentRules[i].Validate(); err != nil {
+			return &FilterError{Index: i, Field: "content type", Err: err}
+		}
+	}
+	return nil
+}
+
+// sniff reads enough of the file at path for Match with any of rules.
+func sniff(path string, rules []ContentRule) ([]byte, error) {
+	n := sniffLen
+	for _, r := range rules {
+		for _, s := range r.Signatures {
+			if end := s.Offset + len(s.Magic); end > n {
+				n = end
+			}
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data := make([]byte, n)
+	if n, err = io.ReadFull(file, data); err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+// checkContent returns a *ContentError if the file at path does not match
+// the rules of the filters it could have been chosen with: the selected
+// filter if the backend reports it and it has a rule, otherwise the filters
+// with a rule whose pattern matches the name. The selected filter may have
+// no rule because it combines others, as "All supported files" of
+// FilterBuilder does. If the backend does not report the filter and no
+// pattern matches, the file must match one of the filters, and a filter
+// without a rule matches every file.
+func checkContent(opts Options, filterIndex int, path string) error {
+	rules := contentRules(opts, filterIndex, path)
+	if len(rules) == 0 {
+		return nil
+	}
+	data, err := sniff(path, rules)
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if r.Match(data) {
+			return nil
+		}
+	}
+	return &ContentError{Path: path, ContentType: http.DetectContentType(data)}
+}
+
+// contentRules returns the rules checkContent checks the file at path
+// against, of which it must match one, or nil to accept any content.
+func contentRules(opts Options, filterIndex int, path string) []ContentRule {
+	if filterIndex >= 0 && filterIndex < len(opts.Filter) {
+		if r := opts.ContentRules[filterIndex]; !r.isZero() {
+			return []ContentRule{r}
+		}
+	}
+	var rules []ContentRule
+	matched := false
+	for i, f := range opts.Filter {
+		if !f.Match(path) {
+			continue
+		}
+		matched = true
+		if r := opts.ContentRules[i]; !r.isZero() {
+			rules = append(rules, r)
+		}
+	}
+	if matched || filterIndex >= 0 {
+		return rules
+	}
+	for i := range opts.Filter {
+		r := opts.ContentRules[i]
+		if r.isZero() {
+			return nil
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
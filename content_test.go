@@ -0,0 +1,211 @@
+package winfileask
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Filter must stay comparable.
+var _ = Filter{} == Filter{}
+
+var (
+	pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	gifData = []byte("GIF89a\x01\x00\x01\x00")
+)
+
+func TestContentRuleMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		rule ContentRule
+		data []byte
+		want bool
+	}{
+		{"zero", ContentRule{}, []byte("anything"), true},
+		{"signature", ContentRule{Signatures: []Signature{{Magic: []byte("\x89PNG")}}}, pngData, true},
+		{"signature mismatch", ContentRule{Signatures: []Signature{{Magic: []byte("\x89PNG")}}}, gifData, false},
+		{"second signature", ContentRule{Signatures: []Signature{{Magic: []byte("GIF87a")}, {Magic: []byte("GIF89a")}}}, gifData, true},
+		{"offset", ContentRule{Signatures: []Signature{{Offset: 12, Magic: []byte("IHDR")}}}, pngData, true},
+		{"past the end", ContentRule{Signatures: []Signature{{Offset: 14, Magic: []byte("IHDR")}}}, pngData, false},
+		{"negative offset", ContentRule{Signatures: []Signature{{Offset: -1, Magic: []byte("\x89")}}}, pngData, false},
+		{"signatures win", ContentRule{Signatures: []Signature{{Magic: []byte("GIF")}}, ContentType: "image/png"}, pngData, false},
+		{"type", ContentRule{ContentType: "image/png"}, pngData, true},
+		{"type case", ContentRule{ContentType: "Image/PNG"}, pngData, true},
+		{"type mismatch", ContentRule{ContentType: "image/png"}, gifData, false},
+		{"range", ContentRule{ContentType: "image/*"}, gifData, true},
+		{"range mismatch", ContentRule{ContentType: "audio/*"}, gifData, false},
+		{"parameters", ContentRule{ContentType: "text/plain; charset=utf-8"}, []byte("hello"), true},
+		{"text", ContentRule{ContentType: "text/plain"}, []byte("a,b\n1,2\n"), true},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.Match(tt.data); got != tt.want {
+			t.Errorf("%s: Match() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestContentRuleValidate(t *testing.T) {
+	tests := []struct {
+		rule ContentRule
+		ok   bool
+	}{
+		{ContentRule{}, true},
+		{ContentRule{ContentType: "image/png"}, true},
+		{ContentRule{ContentType: "IMAGE/PNG; q=1"}, true},
+		{ContentRule{ContentType: "image/*"}, true},
+		{ContentRule{ContentType: "font/*"}, true},
+		{ContentRule{ContentType: "application/pdf"}, true},
+		{ContentRule{ContentType: "text/csv"}, false},
+		{ContentRule{ContentType: "application/json"}, false},
+		{ContentRule{ContentType: "image/svg+xml"}, false},
+		{ContentRule{ContentType: "model/*"}, false},
+		{ContentRule{ContentType: "*/*"}, false},
+		{ContentRule{ContentType: "text/csv", Signatures: []Signature{{Magic: []byte("id,")}}}, true},
+	}
+	for _, tt := range tests {
+		err := tt.rule.Validate()
+		if tt.ok && err != nil || !tt.ok && err != ErrUndetectableType {
+			t.Errorf("Validate(%q) = %v, want ok %v", tt.rule.ContentType, err, tt.ok)
+		}
+	}
+}
+
+func TestDetectableTypes(t *testing.T) {
+	// Each listed type is one that sniffing can produce, and a type for
+	// such data is accepted by Validate.
+	for _, data := range [][]byte{pngData, gifData, []byte("hello"), []byte("%PDF-1.7"), []byte("<html>"), {0, 1, 2}} {
+		rule := ContentRule{ContentType: baseType(http.DetectContentType(data))}
+		if err := rule.Validate(); err != nil {
+			t.Errorf("detected type %q is not in DetectableTypes", rule.ContentType)
+		}
+	}
+}
+
+func TestCheckContent(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"real.png":  pngData,
+		"fake.png":  []byte("not an image"),
+		"anim.gif":  gifData,
+		"notes.txt": []byte("hello"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := Options{
+		Filter: FileFilter{
+			{Name: "PNG", Pattern: "*.png"},
+			{Name: "GIF", Pattern: "*.gif"},
+			{Name: "Text", Pattern: "*.txt"},
+		},
+		ContentRules: map[int]ContentRule{
+			0: {Signatures: []Signature{{Magic: []byte("\x89PNG")}}},
+			1: {ContentType: "image/gif"},
+		},
+	}
+	tests := []struct {
+		name  string
+		index int
+		ok    bool
+	}{
+		{"real.png", 0, true},
+		{"fake.png", 0, false},
+		{"fake.png", -1, false},
+		{"anim.gif", -1, true},
+		{"anim.gif", 0, false},
+		{"notes.txt", 2, true},
+		// Chosen with a filter without a rule, so checked against the
+		// filters its name matches.
+		{"fake.png", 2, false},
+		{"real.png", 2, true},
+		{"notes.txt", 1, false},
+		// Named like no filter, so any of them will do, including the
+		// one without a rule.
+		{"notes.txt", 7, true},
+	}
+	for _, tt := range tests {
+		err := checkContent(opts, tt.index, filepath.Join(dir, tt.name))
+		var ce *ContentError
+		switch {
+		case tt.ok && err != nil:
+			t.Errorf("%s with filter %d: %v", tt.name, tt.index, err)
+		case !tt.ok && !errors.As(err, &ce):
+			t.Errorf("%s with filter %d: err = %v, want a *ContentError", tt.name, tt.index, err)
+		}
+	}
+
+	b := NewDialoger(backendFunc(func(Options) (*Result, error) {
+		return &Result{Paths: []string{filepath.Join(dir, "real.png"), filepath.Join(dir, "fake.png")}, FilterIndex: 0}, nil
+	}))
+	opts.ContentCheck = ContentFlag
+	res, err := b.OpenMultiple(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "fake.png")}; !reflect.DeepEqual(res.Mismatched, want) {
+		t.Errorf("Mismatched = %q, want %q", res.Mismatched, want)
+	}
+	opts.ContentCheck = ContentReject
+	var ce *ContentError
+	if _, err = b.OpenMultiple(opts); !errors.As(err, &ce) || ce.Path != filepath.Join(dir, "fake.png") {
+		t.Errorf("rejected: err = %v", err)
+	}
+}
+
+func TestCheckContentCombinedFilter(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{"real.png": pngData, "fake.png": []byte("MZ\x90\x00"), "notes.txt": []byte("hello")} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// "All supported files" comes first and has no rule of its own.
+	ff := FilterBuilder{AllSupported: true, AllFiles: true}.Build(
+		Filter{Name: "PNG", Pattern: "*.png"},
+		Filter{Name: "Text", Pattern: "*.txt"},
+	)
+	opts := Options{
+		Filter:       ff,
+		ContentRules: map[int]ContentRule{1: {ContentType: "image/png"}},
+		ContentCheck: ContentReject,
+	}
+	for _, index := range []int{0, len(ff) - 1} {
+		if err := checkContent(opts, index, filepath.Join(dir, "real.png")); err != nil {
+			t.Errorf("real.png with %s: %v", ff[index].Name, err)
+		}
+		var ce *ContentError
+		if err := checkContent(opts, index, filepath.Join(dir, "fake.png")); !errors.As(err, &ce) {
+			t.Errorf("fake.png with %s: err = %v, want a *ContentError", ff[index].Name, err)
+		}
+		if err := checkContent(opts, index, filepath.Join(dir, "notes.txt")); err != nil {
+			t.Errorf("notes.txt with %s: %v", ff[index].Name, err)
+		}
+	}
+}
+
+func TestContentRulesValidatedFirst(t *testing.T) {
+	shown := false
+	b := NewDialoger(backendFunc(func(Options) (*Result, error) {
+		shown = true
+		return nil, ErrCanceled
+	}))
+	opts := Options{
+		Filter:       FileFilter{{Name: "Text", Pattern: "*.txt"}, {Name: "CSV", Pattern: "*.csv"}},
+		ContentRules: map[int]ContentRule{0: {ContentType: "text/plain"}, 1: {ContentType: "text/csv"}},
+		ContentCheck: ContentReject,
+	}
+	_, err := b.Open(opts)
+	var fe *FilterError
+	if !errors.As(err, &fe) || fe.Index != 1 || !errors.Is(err, ErrUndetectableType) || shown {
+		t.Errorf("err = %v, shown %v; want a *FilterError for filter 1 before showing", err, shown)
+	}
+	opts.ContentCheck = ContentIgnore
+	if _, err = b.Open(opts); err != ErrCanceled || !shown {
+		t.Errorf("without ContentCheck: err = %v, shown %v", err, shown)
+	}
+}
@@ -0,0 +1,158 @@
+package winfileask
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"golang.org/x/term"
+)
+
+// BackendEnv is the environment variable that overrides the backend chosen by
+// Detect. Its value is one of the names listed in Detection.
+const BackendEnv = "WINFILEASK_BACKEND"
+
+// Detection describes the Backend chosen by Detect.
+type Detection struct {
+	Backend Backend
+	// Name is the short name of the backend: "native", "wsl", "portal",
+	// "zenity", "kdialog", "yad", "tui", "prompt" or, with ScriptEnv,
+	// "replay".
+	Name string
+	// Reason explains why the backend was chosen.
+	Reason string
+}
+
+// String returns the name and reason of the detection.
+func (d Detection) String() string {
+	return d.Name + " (" + d.Reason + ")"
+}
+
+// Detect chooses the most suitable Backend for the environment the program
+// runs in. The native dialogs are used on Windows and, through interop, under
+// WSL. Inside Flatpak the portal is used, and on other graphical desktops
+// zenity, kdialog or yad, falling back to the portal. Without a display the
+// terminal picker is used if standard input and output are terminals, and the
+// line-based prompt otherwise. The choice can be overridden with BackendEnv.
+//
+// If ScriptEnv is set, dialogs are answered from that script file instead.
+// If RecordEnv is set, the chosen backend is wrapped in a Recorder writing to
+// that file.
+func Detect() (Detection, error) {
+	if name := os.Getenv(ScriptEnv); name != "" {
+		r, err := LoadReplay(name)
+		if err != nil {
+			return Detection{}, fmt.Errorf("%s: %v", ScriptEnv, err)
+		}
+		return Detection{r, "replay", ScriptEnv + " is set"}, nil
+	}
+	d, err := detect()
+	if err != nil {
+		return d, err
+	}
+	if name := os.Getenv(RecordEnv); name != "" {
+		d.Backend = &Recorder{Backend: d.Backend, Path: name}
+		d.Reason += "; recording because " + RecordEnv + " is set"
+	}
+	return d, nil
+}
+
+func detect() (Detection, error) {
+	if name := os.Getenv(BackendEnv); name != "" {
+		b, err := backendByName(name)
+		if err != nil {
+			return Detection{}, fmt.Errorf("%s: %v", BackendEnv, err)
+		}
+		return Detection{b, name, BackendEnv + " is set"}, nil
+	}
+	if runtime.GOOS == "windows" {
+		return Detection{nativeBackend(), "native", "running on Windows"}, nil
+	}
+	if IsWSL() {
+		if w, reason, ok := lookWSL(); ok {
+			return Detection{w, "wsl", "running under WSL and " + reason}, nil
+		}
+	}
+	if os.Getenv("FLATPAK_ID") != "" {
+		return Detection{&Portal{}, "portal", "FLATPAK_ID is set"}, nil
+	}
+	if _, err := os.Stat("/.flatpak-info"); err == nil {
+		return Detection{&Portal{}, "portal", "/.flatpak-info exists"}, nil
+	}
+	display := "DISPLAY"
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		display = "WAYLAND_DISPLAY"
+	}
+	if os.Getenv(display) != "" {
+		if s, err := LookSubprocess(); err == nil {
+			return Detection{s, s.Tool.String(), display + " is set and " + s.Tool.String() + " is in PATH"}, nil
+		}
+		if hasSessionBus() {
+			return Detection{&Portal{}, "portal", display + " is set and a session bus is available"}, nil
+		}
+	}
+	reason := "no display"
+	if os.Getenv("SSH_CONNECTION") != "" {
+		reason = "SSH_CONNECTION is set"
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())) {
+		return Detection{&TUI{}, "tui", reason + " and standard input is a terminal"}, nil
+	}
+	return Detection{&Prompt{}, "prompt", reason + " and standard input is not a terminal"}, nil
+}
+
+// backendByName returns the backend with the given Detection name.
+func backendByName(name string) (Backend, error) {
+	switch name {
+	case "native":
+		if b := nativeBackend(); b != nil {
+			return b, nil
+		}
+		return nil, fmt.Errorf("native dialogs are only available on Windows")
+	case "wsl":
+		if w, _, ok := lookWSL(); ok {
+			return w, nil
+		}
+		return nil, fmt.Errorf("neither %s is set nor powershell.exe is in PATH", HelperEnv)
+	case "portal":
+		return &Portal{}, nil
+	case "zenity", "kdialog", "yad":
+		tool := map[string]Tool{"zenity": Zenity, "kdialog": KDialog, "yad": Yad}[name]
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return nil, err
+		}
+		return &Subprocess{Tool: tool, Path: path}, nil
+	case "tui":
+		return &TUI{}, nil
+	case "prompt":
+		return &Prompt{}, nil
+	}
+	return nil, fmt.Errorf("unknown backend %q", name)
+}
+
+// lookWSL returns a WSL backend if a helper for it is available, and which
+// one.
+func lookWSL() (*WSL, string, bool) {
+	if os.Getenv(HelperEnv) != "" {
+		return &WSL{}, HelperEnv + " is set", true
+	}
+	if _, err := exec.LookPath("powershell.exe"); err == nil {
+		return &WSL{}, "powershell.exe is in PATH", true
+	}
+	return nil, "", false
+}
+
+// hasSessionBus reports whether a D-Bus session bus is likely reachable.
+func hasSessionBus() bool {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+		return true
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		if _, err := os.Stat(dir + "/bus"); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+//go:build !windows
+
+package winfileask
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/term"
+)
+
+// detectEnv clears the environment Detect looks at, sets env and gives PATH
+// fake versions of tools, which can be "zenity", "kdialog", "yad" or
+// "powershell.exe".
+func detectEnv(t *testing.T, env map[string]string, tools ...string) {
+	t.Helper()
+	if IsWSL() && env["WSL_DISTRO_NAME"] == "" {
+		if _, err := os.Stat("/proc/sys/fs/binfmt_misc/WSLInterop"); err == nil {
+			t.Skip("running under WSL")
+		}
+	}
+	if _, err := os.Stat("/.flatpak-info"); err == nil {
+		t.Skip("running inside Flatpak")
+	}
+	for _, name := range []string{
+		ScriptEnv, RecordEnv, BackendEnv, HelperEnv,
+		"WSL_INTEROP", "WSL_DISTRO_NAME", "FLATPAK_ID",
+		"DISPLAY", "WAYLAND_DISPLAY", "XDG_CURRENT_DESKTOP",
+		"DBUS_SESSION_BUS_ADDRESS", "XDG_RUNTIME_DIR", "SSH_CONNECTION",
+	} {
+		t.Setenv(name, env[name])
+	}
+	dir := t.TempDir()
+	for _, tool := range tools {
+		if err := os.WriteFile(filepath.Join(dir, tool), []byte(fakeTool), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestDetect(t *testing.T) {
+	terminal := "prompt"
+	if term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())) {
+		terminal = "tui"
+	}
+	tests := []struct {
+		name   string
+		env    map[string]string
+		tools  []string
+		want   string
+		reason string
+	}{
+		{"override", map[string]string{BackendEnv: "prompt", "DISPLAY": ":0"}, []string{"zenity"}, "prompt", BackendEnv + " is set"},
+		{"override tool", map[string]string{BackendEnv: "yad"}, []string{"yad"}, "yad", BackendEnv + " is set"},
+		{"wsl helper", map[string]string{"WSL_DISTRO_NAME": "Ubuntu", HelperEnv: "/helper.exe", "DISPLAY": ":0"}, []string{"zenity"}, "wsl", HelperEnv + " is set"},
+		{"wsl powershell", map[string]string{"WSL_INTEROP": "/run/WSL/1_interop"}, []string{"powershell.exe"}, "wsl", "powershell.exe is in PATH"},
+		{"wsl without interop", map[string]string{"WSL_DISTRO_NAME": "Ubuntu", "DISPLAY": ":0"}, []string{"zenity"}, "zenity", "DISPLAY is set"},
+		{"flatpak", map[string]string{"FLATPAK_ID": "org.example.App", "DISPLAY": ":0"}, []string{"zenity"}, "portal", "FLATPAK_ID is set"},
+		{"x11", map[string]string{"DISPLAY": ":0"}, []string{"zenity", "kdialog"}, "zenity", "DISPLAY is set and zenity is in PATH"},
+		{"kde", map[string]string{"DISPLAY": ":0", "XDG_CURRENT_DESKTOP": "KDE"}, []string{"zenity", "kdialog"}, "kdialog", "kdialog is in PATH"},
+		{"wayland", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, []string{"yad"}, "yad", "WAYLAND_DISPLAY is set"},
+		{"display with session bus", map[string]string{"DISPLAY": ":0", "DBUS_SESSION_BUS_ADDRESS": "unix:path=/bus"}, nil, "portal", "session bus"},
+		{"display without tools", map[string]string{"DISPLAY": ":0"}, nil, terminal, "no display"},
+		{"no display", nil, []string{"zenity"}, terminal, "no display"},
+		{"ssh", map[string]string{"SSH_CONNECTION": "10.0.0.1 5000 10.0.0.2 22"}, []string{"zenity"}, terminal, "SSH_CONNECTION is set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detectEnv(t, tt.env, tt.tools...)
+			d, err := Detect()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Name != tt.want || !strings.Contains(d.Reason, tt.reason) {
+				t.Errorf("Detect() = %v, want %s because %s", d, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func TestDetectErrors(t *testing.T) {
+	for _, name := range []string{"native", "wsl", "zenity", "gtk"} {
+		detectEnv(t, map[string]string{BackendEnv: name})
+		if d, err := Detect(); err == nil || !strings.Contains(err.Error(), BackendEnv) {
+			t.Errorf("%s=%s: Detect() = %v, %v; want an error naming %s", BackendEnv, name, d, err, BackendEnv)
+		}
+	}
+	detectEnv(t, map[string]string{ScriptEnv: filepath.Join(t.TempDir(), "missing")})
+	if _, err := Detect(); err == nil || !strings.Contains(err.Error(), ScriptEnv) {
+		t.Errorf("%s with a missing file: err = %v", ScriptEnv, err)
+	}
+}
+
+func TestDetectScriptAndRecord(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.json")
+	if err := os.WriteFile(script, []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	detectEnv(t, map[string]string{ScriptEnv: script, BackendEnv: "prompt"})
+	d, err := Detect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.Backend.(*Replay); !ok || d.Name != "replay" {
+		t.Errorf("with %s: Detect() = %v, want replay", ScriptEnv, d)
+	}
+
+	record := filepath.Join(t.TempDir(), "record.json")
+	detectEnv(t, map[string]string{RecordEnv: record, BackendEnv: "prompt"})
+	if d, err = Detect(); err != nil {
+		t.Fatal(err)
+	}
+	r, ok := d.Backend.(*Recorder)
+	if !ok || d.Name != "prompt" || r.Path != record || !strings.Contains(d.Reason, RecordEnv) {
+		t.Fatalf("with %s: Detect() = %v, want a recorded prompt", RecordEnv, d)
+	}
+	if _, ok := r.Backend.(*Prompt); !ok {
+		t.Errorf("recorded backend is %T, want *Prompt", r.Backend)
+	}
+}
@@ -0,0 +1,181 @@
+package winfileask
+
+import (
+	"os"
+	"sync"
+)
+
+// Dialoger shows the kinds of file dialogs an application needs. Code that
+// asks for files through a Dialoger, or through the package-level functions,
+// can be tested by substituting the mock of package winfileasktest.
+type Dialoger interface {
+	// Open asks for a single existing file.
+	Open(opts Options) (*Result, error)
+	// OpenMultiple asks for one or more existing files.
+	OpenMultiple(opts Options) (*Result, error)
+	// Save asks for a file name to save to.
+	Save(opts Options) (*Result, error)
+	// Folder asks for a directory.
+	Folder(opts Options) (*Result, error)
+}
+
+// backendDialoger implements Dialoger with a Backend.
+type backendDialoger struct {
+	b Backend
+}
+
+// NewDialoger returns a Dialoger that shows its dialogs with b. Each method
+// sets the Mode of the options, and the AllowMultiSelect flag as needed, and
+// checks the selection as the options ask, for example with ContentCheck and
+// Validate. Save dialogs are given an InitialFileName cleaned with
+// SanitizeFileName, and fail with a *FileNameError if the chosen name is not
+// valid on Windows.
+func NewDialoger(b Backend) Dialoger {
+	return backendDialoger{b}
+}
+
+func (d backendDialoger) Open(opts Options) (*Result, error) {
+	opts.Mode = ModeOpen
+	opts.Flags &^= AllowMultiSelect
+	return d.show(opts)
+}
+
+func (d backendDialoger) OpenMultiple(opts Options) (*Result, error) {
+	opts.Mode = ModeOpen
+	opts.Flags |= AllowMultiSelect
+	return d.show(opts)
+}
+
+func (d backendDialoger) Save(opts Options) (*Result, error) {
+	opts.Mode = ModeSave
+	opts.Flags &^= AllowMultiSelect
+	return d.show(opts)
+}
+
+func (d backendDialoger) Folder(opts Options) (*Result, error) {
+	opts.Mode = ModeFolder
+	opts.Flags &^= AllowMultiSelect
+	return d.show(opts)
+}
+
+func (d backendDialoger) show(opts Options) (*Result, error) {
+	if opts.Mode == ModeOpen && opts.ContentCheck != ContentIgnore {
+		if err := validateContentRules(opts); err != nil {
+			return nil, err
+		}
+	}
+	opts.InitialDir = opts.startDir()
+	if opts.Mode == ModeSave && opts.InitialFileName != "" {
+		opts.InitialFileName = SanitizeFileName(opts.InitialFileName)
+		if opts.UniqueFileName {
+			dir := opts.InitialDir
+			if dir == "" {
+				dir = "."
+			}
+			opts.InitialFileName = SuggestSaveName(os.DirFS(dir), ".", opts.InitialFileName)
+		}
+	}
+	res, err := d.b.Show(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkResult(opts, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// errDialoger is a Dialoger whose methods all fail with the same error.
+type errDialoger struct {
+	err error
+}
+
+func (d errDialoger) Open(Options) (*Result, error)         { return nil, d.err }
+func (d errDialoger) OpenMultiple(Options) (*Result, error) { return nil, d.err }
+func (d errDialoger) Save(Options) (*Result, error)         { return nil, d.err }
+func (d errDialoger) Folder(Options) (*Result, error)       { return nil, d.err }
+
+var (
+	defaultMu sync.Mutex
+	// installed is the Dialoger set with SetDefault, and detected the one
+	// built from Detect the first time Default needed it.
+	installed Dialoger
+	detected  Dialoger
+)
+
+// Default returns the Dialoger used by the package-level Open, OpenMultiple,
+// Save and Folder functions. Unless it was replaced with SetDefault, it uses
+// the Backend chosen by Detect.
+func Default() Dialoger {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if installed != nil {
+		return installed
+	}
+	if detected == nil {
+		d, err := Detect()
+		if err != nil {
+			return errDialoger{err}
+		}
+		detected = NewDialoger(d.Backend)
+	}
+	return detected
+}
+
+// SetDefault replaces the Dialoger used by the package-level functions and
+// returns the one previously set, or nil if there was none. Passing nil
+// restores the detected default. A Dialoger set here also answers
+// GetOpenFileName and GetSaveFileName.
+func SetDefault(d Dialoger) Dialoger {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	prev := installed
+	installed = d
+	return prev
+}
+
+// installedDialoger returns the Dialoger set with SetDefault, or nil.
+func installedDialoger() Dialoger {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return installed
+}
+
+// dialogerFileName answers GetOpenFileName or GetSaveFileName with show, a
+// method of an installed Dialoger, translating its result to the
+// (path, ok, err) form of those functions.
+func dialogerFileName(show func(Options) (*Result, error), opts Options) (string, bool, error) {
+	var res *Result
+	var err error
+	if res, err = show(opts); err != nil {
+		if err == ErrCanceled {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if len(res.Paths) == 0 {
+		return "", false, nil
+	}
+	return res.Paths[0], true, nil
+}
+
+// Open asks for a single existing file using the default Dialoger.
+func Open(opts Options) (*Result, error) {
+	return Default().Open(opts)
+}
+
+// OpenMultiple asks for one or more existing files using the default
+// Dialoger.
+func OpenMultiple(opts Options) (*Result, error) {
+	return Default().OpenMultiple(opts)
+}
+
+// Save asks for a file name to save to using the default Dialoger.
+func Save(opts Options) (*Result, error) {
+	return Default().Save(opts)
+}
+
+// Folder asks for a directory using the default Dialoger.
+func Folder(opts Options) (*Result, error) {
+	return Default().Folder(opts)
+}
@@ -0,0 +1,72 @@
+package winfileask
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// dialogerFunc is a Dialoger whose methods all call it.
+type dialogerFunc func(opts Options) (*Result, error)
+
+func (f dialogerFunc) Open(opts Options) (*Result, error)         { return f(opts) }
+func (f dialogerFunc) OpenMultiple(opts Options) (*Result, error) { return f(opts) }
+func (f dialogerFunc) Save(opts Options) (*Result, error)         { return f(opts) }
+func (f dialogerFunc) Folder(opts Options) (*Result, error)       { return f(opts) }
+
+func TestSetDefault(t *testing.T) {
+	var calls int
+	d := dialogerFunc(func(Options) (*Result, error) {
+		calls++
+		return &Result{Paths: []string{"a"}}, nil
+	})
+	if prev := SetDefault(d); prev != nil {
+		SetDefault(prev)
+		t.Fatalf("a default was already installed: %v", prev)
+	}
+	defer SetDefault(nil)
+	if got := installedDialoger(); got == nil {
+		t.Fatal("installedDialoger() = nil after SetDefault")
+	}
+	Open(Options{})
+	Save(Options{})
+	if calls != 2 {
+		t.Errorf("installed Dialoger called %d times, want 2", calls)
+	}
+	if prev := SetDefault(nil); prev == nil {
+		t.Error("SetDefault(nil) did not return the installed Dialoger")
+	}
+	if got := installedDialoger(); got != nil {
+		t.Errorf("installedDialoger() = %v after SetDefault(nil)", got)
+	}
+}
+
+func TestDialogerFileName(t *testing.T) {
+	failure := errors.New("failure")
+	tests := []struct {
+		res  *Result
+		err  error
+		path string
+		ok   bool
+		want error
+	}{
+		{&Result{Paths: []string{`C:\a.txt`, `C:\b.txt`}}, nil, `C:\a.txt`, true, nil},
+		{&Result{}, nil, "", false, nil},
+		{nil, ErrCanceled, "", false, nil},
+		{nil, failure, "", false, failure},
+	}
+	opts := Options{Title: "T", Filter: testFilter, InitialDir: `C:\`, Flags: OpenFlags}
+	for _, tt := range tests {
+		var got Options
+		path, ok, err := dialogerFileName(func(o Options) (*Result, error) {
+			got = o
+			return tt.res, tt.err
+		}, opts)
+		if path != tt.path || ok != tt.ok || err != tt.want {
+			t.Errorf("answer %v, %v: got %q, %v, %v; want %q, %v, %v", tt.res, tt.err, path, ok, err, tt.path, tt.ok, tt.want)
+		}
+		if !reflect.DeepEqual(got, opts) {
+			t.Errorf("options = %+v, want %+v", got, opts)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package winfileask
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// ExtensionPolicy restricts the extensions of the files that can be opened or
+// saved. Extensions are compared without regard to case and may contain
+// several dots, so ".tar.gz" and "gz" both match "backup.tar.gz". The leading
+// dot is optional.
+type ExtensionPolicy struct {
+	// Allow, if not empty, lists the only extensions accepted.
+	Allow []string
+	// Deny lists extensions that are never accepted, even if allowed.
+	Deny []string
+}
+
+// ExtensionError reports a file whose extension an ExtensionPolicy does not
+// accept.
+type ExtensionError struct {
+	Path string
+	// Ext is the denied extension, or empty if the extension is not in
+	// the allow list.
+	Ext string
+}
+
+func (e *ExtensionError) Error() string {
+	if e.Ext == "" {
+		return fmt.Sprintf("%s: this type of file is not allowed", e.Path)
+	}
+	return fmt.Sprintf("%s: files of type %s are not allowed", e.Path, e.Ext)
+}
+
+// Check returns an *ExtensionError if p does not accept the file at path.
+// Trailing dots and spaces, which Windows drops, are ignored. On Windows, or
+// for a path with a drive or a UNC prefix, alternate data stream names after
+// a colon are ignored too, as is the drive of a drive-relative path; in other
+// paths a colon is part of the name.
+func (p ExtensionPolicy) Check(path string) error {
+	name := baseName(path)
+	if runtime.GOOS == "windows" || hasDrive(path) || strings.HasPrefix(path, `\\`) {
+		// A drive-relative path such as C:file.exe has no separator.
+		if name == path && hasDrive(name) {
+			name = name[2:]
+		}
+		if i := strings.IndexByte(name, ':'); i >= 0 {
+			name = name[:i]
+		}
+	}
+	name = strings.ToLower(strings.TrimRight(name, ". "))
+	for _, ext := range p.Deny {
+		if hasExtension(name, ext) {
+			return &ExtensionError{Path: path, Ext: "." + strings.TrimPrefix(strings.ToLower(ext), ".")}
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for _, ext := range p.Allow {
+		if hasExtension(name, ext) {
+			return nil
+		}
+	}
+	return &ExtensionError{Path: path}
+}
+
+// hasDrive reports whether path starts with a drive letter and a colon.
+func hasDrive(path string) bool {
+	return len(path) >= 2 && path[1] == ':' &&
+		('a' <= path[0] && path[0] <= 'z' || 'A' <= path[0] && path[0] <= 'Z')
+}
+
+// isZero reports whether p accepts every file.
+func (p ExtensionPolicy) isZero() bool {
+	return len(p.Allow) == 0 && len(p.Deny) == 0
+}
+
+// hasExtension reports whether name, in lower case, ends with the extension
+// ext and has something before it.
+func hasExtension(name, ext string) bool {
+	ext = "." + strings.TrimPrefix(strings.ToLower(ext), ".")
+	return ext != "." && len(name) > len(ext) && strings.HasSuffix(name, ext)
+}
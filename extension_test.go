@@ -0,0 +1,115 @@
+package winfileask
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestExtensionPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy ExtensionPolicy
+		path   string
+		// ext is the denied extension reported, "allow" for a file missing
+		// from the allow list, or "" if the file is accepted.
+		ext string
+	}{
+		{"zero", ExtensionPolicy{}, "a.exe", ""},
+		{"allowed", ExtensionPolicy{Allow: []string{".txt"}}, "a.txt", ""},
+		{"allowed without dot", ExtensionPolicy{Allow: []string{"txt"}}, "a.txt", ""},
+		{"allowed case", ExtensionPolicy{Allow: []string{".TXT"}}, `C:\Docs\A.Txt`, ""},
+		{"not allowed", ExtensionPolicy{Allow: []string{".txt"}}, "a.md", "allow"},
+		{"no extension", ExtensionPolicy{Allow: []string{".txt"}}, "txt", "allow"},
+		{"only the extension", ExtensionPolicy{Allow: []string{".txt"}}, ".txt", "allow"},
+		{"extension of a folder", ExtensionPolicy{Allow: []string{".txt"}}, `C:\a.txt\b`, "allow"},
+		{"denied", ExtensionPolicy{Deny: []string{"exe"}}, "/tmp/a.exe", ".exe"},
+		{"denied case", ExtensionPolicy{Deny: []string{".Exe"}}, "A.EXE", ".exe"},
+		{"deny wins", ExtensionPolicy{Allow: []string{".exe"}, Deny: []string{".exe"}}, "a.exe", ".exe"},
+		{"empty extensions", ExtensionPolicy{Allow: []string{"", "."}}, "a.txt", "allow"},
+
+		// Names with several dots.
+		{"double extension", ExtensionPolicy{Allow: []string{".tar.gz"}}, "backup.tar.gz", ""},
+		{"last part of a double extension", ExtensionPolicy{Allow: []string{"gz"}}, "backup.tar.gz", ""},
+		{"double extension not matched", ExtensionPolicy{Allow: []string{".tar.gz"}}, "backup.gz", "allow"},
+		{"double extension not a suffix", ExtensionPolicy{Allow: []string{".tar.gz"}}, "backup.xtar.gz", "allow"},
+		{"double extension case", ExtensionPolicy{Allow: []string{".tar.gz"}}, "BACKUP.TAR.GZ", ""},
+		{"hidden double extension", ExtensionPolicy{Deny: []string{".exe"}}, "invoice.pdf.exe", ".exe"},
+		{"inner extension", ExtensionPolicy{Deny: []string{".exe"}}, "setup.exe.txt", ""},
+		{"deny part of an allowed extension", ExtensionPolicy{Allow: []string{".tar.gz"}, Deny: []string{".gz"}}, "a.tar.gz", ".gz"},
+		{"dot file", ExtensionPolicy{Allow: []string{".txt"}}, ".notes.txt", ""},
+		{"dots in folders", ExtensionPolicy{Allow: []string{".txt"}}, "/srv/v1.2/a.b.txt", ""},
+
+		// Names Windows changes.
+		{"trailing dot", ExtensionPolicy{Deny: []string{".exe"}}, "a.exe.", ".exe"},
+		{"trailing dots and spaces", ExtensionPolicy{Deny: []string{".exe"}}, `C:\a.exe . .`, ".exe"},
+		{"trailing dot allowed", ExtensionPolicy{Allow: []string{".txt"}}, "a.txt.", ""},
+
+		// Alternate data streams.
+		{"stream", ExtensionPolicy{Deny: []string{".exe"}}, `C:\a.exe:stream`, ".exe"},
+		{"default stream", ExtensionPolicy{Deny: []string{".exe"}}, `C:\a.exe::$DATA`, ".exe"},
+		{"stream with a trailing dot", ExtensionPolicy{Deny: []string{".exe"}}, `C:\a.exe.:s:$DATA`, ".exe"},
+		{"stream name with an extension", ExtensionPolicy{Allow: []string{".txt"}}, `C:\a.txt:payload.exe`, ""},
+		{"stream name hides nothing", ExtensionPolicy{Allow: []string{".exe"}}, `C:\a.txt:payload.exe`, "allow"},
+		{"stream of a denied file", ExtensionPolicy{Deny: []string{".exe"}}, `C:\a.exe:notes.txt:$DATA`, ".exe"},
+		{"drive-relative", ExtensionPolicy{Deny: []string{".exe"}}, `C:a.exe`, ".exe"},
+		{"drive-relative stream", ExtensionPolicy{Deny: []string{".exe"}}, `c:a.exe:s`, ".exe"},
+	}
+	for _, tt := range tests {
+		err := tt.policy.Check(tt.path)
+		if tt.ext == "" {
+			if err != nil {
+				t.Errorf("%s: Check(%q) = %v, want nil", tt.name, tt.path, err)
+			}
+			continue
+		}
+		want := tt.ext
+		if want == "allow" {
+			want = ""
+		}
+		if ee, ok := err.(*ExtensionError); !ok || ee.Path != tt.path || ee.Ext != want {
+			t.Errorf("%s: Check(%q) = %v, want an *ExtensionError with Ext %q", tt.name, tt.path, err, want)
+		}
+	}
+}
+
+func TestExtensionPolicyColon(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("a colon starts a stream name on Windows")
+	}
+	// Outside Windows paths, a colon is part of the file name.
+	policy := ExtensionPolicy{Deny: []string{".exe"}}
+	for _, path := range []string{"a:b.exe", "/tmp/a:b.exe", "/tmp/C:a.exe", "ab:c.exe"} {
+		if err := policy.Check(path); err == nil {
+			t.Errorf("Check(%q) = nil, want an *ExtensionError", path)
+		}
+	}
+	if err := policy.Check("/tmp/a.exe:notes"); err != nil {
+		t.Errorf("Check(%q) = %v, want nil", "/tmp/a.exe:notes", err)
+	}
+}
+
+func TestExtensionErrorMessage(t *testing.T) {
+	if got, want := (&ExtensionError{Path: "a.exe", Ext: ".exe"}).Error(), "a.exe: files of type .exe are not allowed"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got, want := (&ExtensionError{Path: "a.md"}).Error(), "a.md: this type of file is not allowed"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestExtensionsDialoger(t *testing.T) {
+	d := NewDialoger(backendFunc(func(Options) (*Result, error) {
+		return &Result{Paths: []string{"/srv/folder.d"}, FilterIndex: -1}, nil
+	}))
+	policy := ExtensionPolicy{Allow: []string{".txt"}}
+	if _, err := d.Open(Options{Extensions: policy}); err == nil {
+		t.Error("open: err = nil, want an *ExtensionError")
+	}
+	if _, err := d.Save(Options{Extensions: policy}); err == nil {
+		t.Error("save: err = nil, want an *ExtensionError")
+	}
+	// Folders have no extension to check.
+	if _, err := d.Folder(Options{Extensions: policy}); err != nil {
+		t.Errorf("folder: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package winfileask
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// The problems reported by ValidateFileName, wrapped in a *FileNameError.
+var (
+	ErrInvalidChar      = errors.New("contains a character Windows does not allow")
+	ErrReservedName     = errors.New("is reserved by Windows")
+	ErrTrailingDotSpace = errors.New("ends with a dot or a space")
+	ErrNameTooLong      = errors.New("is longer than 255 UTF-16 code units")
+)
+
+// maxFileName is the maximum length of a file name, in UTF-16 code units.
+const maxFileName = 255
+
+// FileNameError reports a file name that Windows does not allow.
+type FileNameError struct {
+	Name string
+	Err  error
+}
+
+func (e *FileNameError) Error() string {
+	return fmt.Sprintf("file name %q %v", e.Name, e.Err)
+}
+
+func (e *FileNameError) Unwrap() error {
+	return e.Err
+}
+
+// reservedNames are the device names Windows reserves, with or without an
+// extension and in any case.
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"CONIN$": true, "CONOUT$": true,
+	"COM0": true, "COM1": true, "COM2": true, "COM3": true, "COM4": true,
+	"COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"COM¹": true, "COM²": true, "COM³": true,
+	"LPT0": true, "LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true,
+	"LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+	"LPT¹": true, "LPT²": true, "LPT³": true,
+}
+
+// ValidateFileName reports whether name, a single path element, is a valid
+// file name on Windows. It returns a *FileNameError if name is empty, is "."
+// or "..", contains a control character or one of <>:"/\|?*, ends with a dot
+// or a space, is a reserved device name such as CON or COM1.txt, or is longer
+// than 255 UTF-16 code units.
+func ValidateFileName(name string) error {
+	switch {
+	case name == "":
+		return &FileNameError{name, ErrEmptyName}
+	case name == "." || name == "..":
+		return &FileNameError{name, ErrReservedName}
+	case !utf8.ValidString(name) || strings.IndexFunc(name, invalidFileNameRune) >= 0:
+		return &FileNameError{name, ErrInvalidChar}
+	case strings.HasSuffix(name, ".") || strings.HasSuffix(name, " "):
+		return &FileNameError{name, ErrTrailingDotSpace}
+	case isReservedName(name):
+		return &FileNameError{name, ErrReservedName}
+	case len(utf16.Encode([]rune(name))) > maxFileName:
+		return &FileNameError{name, ErrNameTooLong}
+	}
+	return nil
+}
+
+// SanitizeFileName returns name changed as little as possible to pass
+// ValidateFileName: forbidden characters are replaced with '_', trailing dots
+// and spaces are removed, reserved names are prefixed with '_' and long names
+// are shortened before their extension. An empty result is replaced with "_".
+func SanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if invalidFileNameRune(r) {
+			return '_'
+		}
+		return r
+	}, strings.ToValidUTF8(name, "_"))
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "_"
+	}
+	if isReservedName(name) {
+		name = "_" + name
+	}
+	if u := utf16.Encode([]rune(name)); len(u) > maxFileName {
+		ext := ""
+		if i := strings.LastIndexByte(name, '.'); i > 0 && len(utf16.Encode([]rune(name[i:]))) < maxFileName/2 {
+			ext = name[i:]
+		}
+		stem := []rune(strings.TrimSuffix(name, ext))
+		for len(utf16.Encode(stem))+len(utf16.Encode([]rune(ext))) > maxFileName {
+			stem = stem[:len(stem)-1]
+		}
+		name = strings.TrimRight(string(stem), ". ") + ext
+	}
+	return name
+}
+
+// SuggestSaveName returns name, or if a file of that name already exists in
+// the directory dir of fsys, the first free name numbered as Explorer does:
+// "Report.pdf" becomes "Report (2).pdf", then "Report (3).pdf", and
+// "Report (2).pdf" becomes "Report (3).pdf". Names are compared without
+// regard to case. If dir cannot be read, name is returned unchanged.
+func SuggestSaveName(fsys fs.FS, dir, name string) string {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return name
+	}
+	taken := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		taken[strings.ToLower(e.Name())] = true
+	}
+	if !taken[strings.ToLower(name)] {
+		return name
+	}
+	stem, ext := name, ""
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		stem, ext = name[:i], name[i:]
+	}
+	n := 2
+	if i := strings.LastIndex(stem, " ("); i >= 0 && strings.HasSuffix(stem, ")") {
+		if m, err := strconv.Atoi(stem[i+2 : len(stem)-1]); err == nil && m >= 1 {
+			stem, n = stem[:i], m+1
+		}
+	}
+	for ; ; n++ {
+		next := stem + " (" + strconv.Itoa(n) + ")" + ext
+		if !taken[strings.ToLower(next)] {
+			return next
+		}
+	}
+}
+
+func invalidFileNameRune(r rune) bool {
+	return r < 0x20 || strings.ContainsRune(`<>:"/\|?*`, r)
+}
+
+// isReservedName reports whether name is a device name, ignoring case, any
+// extension and spaces before the extension.
+func isReservedName(name string) bool {
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	return reservedNames[strings.ToUpper(strings.TrimRight(name, " "))]
+}
@@ -0,0 +1,192 @@
+package winfileask
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"unicode/utf16"
+)
+
+func TestValidateFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"report.pdf", nil},
+		{"Zoë ✓.txt", nil},
+		{".hidden", nil},
+		{"two words", nil},
+		{"a\x7fb", nil},
+		{"", ErrEmptyName},
+		{".", ErrReservedName},
+		{"..", ErrReservedName},
+		{"a<b", ErrInvalidChar},
+		{"a>b", ErrInvalidChar},
+		{"a:b", ErrInvalidChar},
+		{`a"b`, ErrInvalidChar},
+		{"a/b", ErrInvalidChar},
+		{`a\b`, ErrInvalidChar},
+		{"a|b", ErrInvalidChar},
+		{"what?", ErrInvalidChar},
+		{"*.txt", ErrInvalidChar},
+		{"tab\there", ErrInvalidChar},
+		{"nul\x00", ErrInvalidChar},
+		{"\xffname", ErrInvalidChar},
+		{"name.", ErrTrailingDotSpace},
+		{"name ", ErrTrailingDotSpace},
+		{"name. .", ErrTrailingDotSpace},
+		{"CON", ErrReservedName},
+		{"con.txt", ErrReservedName},
+		{"Com1.tar.gz", ErrReservedName},
+		{"LPT¹", ErrReservedName},
+		{"CON .txt", ErrReservedName},
+		{"conin$", ErrReservedName},
+		{"CONSOLE", nil},
+		{"COM10", nil},
+		{"xCON", nil},
+		{strings.Repeat("a", 255), nil},
+		{strings.Repeat("a", 256), ErrNameTooLong},
+		{strings.Repeat("😀", 127), nil},
+		{strings.Repeat("😀", 128), ErrNameTooLong},
+	}
+	for _, tt := range tests {
+		err := ValidateFileName(tt.name)
+		if tt.err == nil {
+			if err != nil {
+				t.Errorf("ValidateFileName(%.20q) = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		fe, ok := err.(*FileNameError)
+		if !ok || fe.Err != tt.err || fe.Name != tt.name {
+			t.Errorf("ValidateFileName(%.20q) = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+	if err := ValidateFileName("a:b"); err == nil || err.Error() != `file name "a:b" contains a character Windows does not allow` {
+		t.Errorf("message = %v", err)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"Zoë ✓.txt", "Zoë ✓.txt"},
+		{"a:b?c.txt", "a_b_c.txt"},
+		{`dir\file/name`, "dir_file_name"},
+		{"a\x00b\nc", "a_b_c"},
+		{"\xffname", "_name"},
+		{"name. . ", "name"},
+		{"...", "_"},
+		{"", "_"},
+		{"CON", "_CON"},
+		{"con.txt", "_con.txt"},
+		{"LPT1 .log", "_LPT1 .log"},
+		{strings.Repeat("a", 300) + ".txt", strings.Repeat("a", 251) + ".txt"},
+		{strings.Repeat("a", 300), strings.Repeat("a", 255)},
+		{strings.Repeat("😀", 200) + ".png", strings.Repeat("😀", 125) + ".png"},
+		// An extension too long to keep is cut like the rest of the name.
+		{"a." + strings.Repeat("b", 300), "a." + strings.Repeat("b", 253)},
+		// Dots and spaces left at the end of the shortened stem go too.
+		{strings.Repeat("a", 250) + " . " + strings.Repeat("c", 10) + ".txt", strings.Repeat("a", 250) + ".txt"},
+	}
+	for _, tt := range tests {
+		got := SanitizeFileName(tt.name)
+		if got != tt.want {
+			t.Errorf("SanitizeFileName(%.20q) = %.30q (%d), want %.30q (%d)", tt.name, got, len(got), tt.want, len(tt.want))
+		}
+		if err := ValidateFileName(got); err != nil {
+			t.Errorf("SanitizeFileName(%.20q) is invalid: %v", tt.name, err)
+		}
+	}
+}
+
+func FuzzSanitizeFileName(f *testing.F) {
+	for _, s := range []string{"report.pdf", "a:b", "CON.txt", "name. ", "", strings.Repeat("😀", 200) + ".x"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		got := SanitizeFileName(name)
+		if err := ValidateFileName(got); err != nil {
+			t.Fatalf("SanitizeFileName(%q) = %q, which is invalid: %v", name, got, err)
+		}
+		if ValidateFileName(name) == nil && got != name {
+			t.Errorf("SanitizeFileName changed the valid name %q to %q", name, got)
+		}
+		if n := len(utf16.Encode([]rune(got))); n > maxFileName {
+			t.Errorf("SanitizeFileName(%q) is %d code units long", name, n)
+		}
+	})
+}
+
+func TestSuggestSaveName(t *testing.T) {
+	fsys := fstest.MapFS{
+		"Report.pdf":           {},
+		"Report (2).pdf":       {},
+		"notes.TXT":            {},
+		"Makefile":             {},
+		".env":                 {},
+		"a.tar.gz":             {},
+		"Draft (0).doc":        {},
+		"Draft (x).doc":        {},
+		"Draft (4).doc":        {},
+		"folder/inner.txt":     {},
+		"folder/inner (2).txt": {},
+		"photos/x":             {},
+	}
+	tests := []struct {
+		dir, name, want string
+	}{
+		{".", "Free.pdf", "Free.pdf"},
+		{".", "Report.pdf", "Report (3).pdf"},
+		{".", "Report (2).pdf", "Report (3).pdf"},
+		{".", "report.PDF", "report (3).PDF"},
+		{".", "Notes.txt", "Notes (2).txt"},
+		{".", "Makefile", "Makefile (2)"},
+		{".", ".env", ".env (2)"},
+		{".", "a.tar.gz", "a.tar (2).gz"},
+		{".", "Draft (0).doc", "Draft (0) (2).doc"},
+		{".", "Draft (x).doc", "Draft (x) (2).doc"},
+		{".", "Draft (4).doc", "Draft (5).doc"},
+		{".", "photos", "photos (2)"},
+		{"folder", "inner.txt", "inner (3).txt"},
+		{"folder", "Report.pdf", "Report.pdf"},
+		{"missing", "Report.pdf", "Report.pdf"},
+	}
+	for _, tt := range tests {
+		if got := SuggestSaveName(fsys, tt.dir, tt.name); got != tt.want {
+			t.Errorf("SuggestSaveName(%q, %q) = %q, want %q", tt.dir, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSaveInitialFileName(t *testing.T) {
+	dir := makeTree(t, "a.txt", "a (2).txt")
+	var got Options
+	d := NewDialoger(backendFunc(func(opts Options) (*Result, error) {
+		got = opts
+		return nil, ErrCanceled
+	}))
+	tests := []struct {
+		name   string
+		unique bool
+		want   string
+	}{
+		{"a.txt", false, "a.txt"},
+		{"a.txt", true, "a (3).txt"},
+		{"b.txt", true, "b.txt"},
+		{"a:b?.txt", false, "a_b_.txt"},
+		{"con", true, "_con"},
+	}
+	for _, tt := range tests {
+		d.Save(Options{InitialDir: dir, InitialFileName: tt.name, UniqueFileName: tt.unique})
+		if got.InitialFileName != tt.want {
+			t.Errorf("initial name %q (unique %v) became %q, want %q", tt.name, tt.unique, got.InitialFileName, tt.want)
+		}
+	}
+	d.Open(Options{InitialDir: dir, InitialFileName: "a:b", UniqueFileName: true})
+	if got.InitialFileName != "a:b" {
+		t.Errorf("open dialog initial name became %q", got.InitialFileName)
+	}
+}
@@ -0,0 +1,166 @@
+package winfileask
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PortalFilter is a filter in the (sa(us)) format of the XDG Desktop Portal
+// FileChooser interface.
+type PortalFilter struct {
+	Name  string
+	Rules []PortalRule
+}
+
+// PortalRule is a single rule of a PortalFilter.
+type PortalRule struct {
+	// Kind is 0 for a glob pattern and 1 for a MIME type.
+	Kind    uint32
+	Pattern string
+}
+
+// Globs returns the semicolon separated patterns of f as a list.
+func (f Filter) Globs() []string {
+	var globs []string
+	for _, p := range strings.Split(f.Pattern, ";") {
+		if p = strings.TrimSpace(p); p != "" {
+			globs = append(globs, p)
+		}
+	}
+	return globs
+}
+
+// GTKGlobs returns the patterns of f for a GtkFileFilter. GTK matches
+// patterns case-sensitively, so every letter is turned into a bracket
+// expression matching both cases, as in "*.[pP][nN][gG]", to keep the
+// case-insensitive behavior of Windows.
+func (f Filter) GTKGlobs() []string {
+	globs := f.Globs()
+	for i, g := range globs {
+		var b strings.Builder
+		for _, r := range g {
+			lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+			switch {
+			case r == '[' || r == ']':
+				b.WriteString("[" + string(r) + "]")
+			case lower != upper:
+				b.WriteString("[" + string(lower) + string(upper) + "]")
+			default:
+				b.WriteRune(r)
+			}
+		}
+		globs[i] = b.String()
+	}
+	return globs
+}
+
+// ZenityArgs returns the --file-filter arguments of zenity and yad for ff.
+// A name or pattern containing '|', which separates them in the argument, is
+// reported as a *FilterError wrapping ErrSeparator.
+func (ff FileFilter) ZenityArgs() ([]string, error) {
+	if err := ff.checkSeparator("|", "|"); err != nil {
+		return nil, err
+	}
+	args := make([]string, len(ff))
+	for i, f := range ff {
+		args[i] = "--file-filter=" + f.Name + " | " + strings.Join(f.GTKGlobs(), " ")
+	}
+	return args, nil
+}
+
+// KDialogString returns ff in the format of kdialog's filter argument, with
+// one filter per line, such as "Images (*.png *.jpg)\nText files (*.txt)". A
+// name or pattern containing a newline, or '|', which separates patterns
+// from a label in that format, is reported as a *FilterError wrapping
+// ErrSeparator.
+func (ff FileFilter) KDialogString() (string, error) {
+	for _, sep := range []string{"\n", "|"} {
+		if err := ff.checkSeparator(sep, sep); err != nil {
+			return "", err
+		}
+	}
+	entries := make([]string, len(ff))
+	for i, f := range ff {
+		entries[i] = f.Name + " (" + strings.Join(f.Globs(), " ") + ")"
+	}
+	return strings.Join(entries, "\n"), nil
+}
+
+// PortalFilters returns ff as filters of the XDG Desktop Portal.
+func (ff FileFilter) PortalFilters() []PortalFilter {
+	filters := make([]PortalFilter, len(ff))
+	for i, f := range ff {
+		filters[i].Name = f.Name
+		for _, g := range f.GTKGlobs() {
+			filters[i].Rules = append(filters[i].Rules, PortalRule{Kind: 0, Pattern: g})
+		}
+	}
+	return filters
+}
+
+// QtString returns ff in the format of Qt's QFileDialog, such as
+// "Images (*.png *.jpg);;Text files (*.txt)". ParseQtFilter reverses it. A
+// name containing ";;", or a pattern containing a parenthesis, is reported
+// as a *FilterError wrapping ErrSeparator.
+func (ff FileFilter) QtString() (string, error) {
+	if err := ff.checkSeparator(";;", "()"); err != nil {
+		return "", err
+	}
+	entries := make([]string, len(ff))
+	for i, f := range ff {
+		entries[i] = f.Name + " (" + strings.Join(f.Globs(), " ") + ")"
+	}
+	return strings.Join(entries, ";;"), nil
+}
+
+// WxString returns ff in the format of wxWidgets' wxFileDialog, such as
+// "Images|*.png;*.jpg|Text files|*.txt". ParseWxFilter reverses it. A name
+// or pattern containing '|' is reported as a *FilterError wrapping
+// ErrSeparator.
+func (ff FileFilter) WxString() (string, error) {
+	if err := ff.checkSeparator("|", "|"); err != nil {
+		return "", err
+	}
+	fields := make([]string, 0, 2*len(ff))
+	for _, f := range ff {
+		fields = append(fields, f.Name, strings.Join(f.Globs(), ";"))
+	}
+	return strings.Join(fields, "|"), nil
+}
+
+// checkSeparator reports the first name containing sep, or pattern
+// containing any of the characters in chars.
+func (ff FileFilter) checkSeparator(sep, chars string) error {
+	for i, f := range ff {
+		if strings.Contains(f.Name, sep) {
+			return &FilterError{i, "name", ErrSeparator}
+		}
+		if strings.ContainsAny(f.Pattern, chars) {
+			return &FilterError{i, "pattern", ErrSeparator}
+		}
+	}
+	return nil
+}
+
+// HTMLAccept returns ff as the value of the accept attribute of an HTML
+// <input type="file">, such as ".png,.jpg,.txt". Only patterns of the form
+// "*.ext" can be expressed; if any filter accepts every file, the result is
+// empty, which accepts every file too.
+func (ff FileFilter) HTMLAccept() string {
+	var exts []string
+	seen := make(map[string]bool)
+	for _, f := range ff {
+		for _, g := range f.Globs() {
+			if g == "*" || g == "*.*" {
+				return ""
+			}
+			ext := strings.ToLower(strings.TrimPrefix(g, "*"))
+			if !strings.HasPrefix(g, "*.") || strings.ContainsAny(ext, "*?") || seen[ext] {
+				continue
+			}
+			seen[ext] = true
+			exts = append(exts, ext)
+		}
+	}
+	return strings.Join(exts, ",")
+}
@@ -0,0 +1,103 @@
+package winfileask
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQtWxRoundTrip(t *testing.T) {
+	tests := []struct {
+		ff FileFilter
+		qt string
+		wx string
+	}{
+		{testFilter, "Text (*.txt);;Images (*.png *.jpg)", "Text|*.txt|Images|*.png;*.jpg"},
+		{FileFilter{{Name: "All files", Pattern: "*"}}, "All files (*)", "All files|*"},
+		{FileFilter{{Name: "Data (v2)", Pattern: "*.dat"}}, "Data (v2) (*.dat)", "Data (v2)|*.dat"},
+		{FileFilter{{Name: "A)", Pattern: "*.a"}}, "A) (*.a)", "A)|*.a"},
+		{FileFilter{{Name: "Semi;colon", Pattern: "*.a;;*.b"}}, "Semi;colon (*.a *.b)", "Semi;colon|*.a;*.b"},
+		{FileFilter{{Name: "Ünïcode ✓", Pattern: "*.ü"}}, "Ünïcode ✓ (*.ü)", "Ünïcode ✓|*.ü"},
+		{FileFilter{{Name: "Logs", Pattern: "app-??.log;*.[0-9]"}}, "Logs (app-??.log *.[0-9])", "Logs|app-??.log;*.[0-9]"},
+	}
+	for _, tt := range tests {
+		// The parsers join patterns with single semicolons.
+		want := make(FileFilter, len(tt.ff))
+		for i, f := range tt.ff {
+			want[i] = Filter{Name: f.Name, Pattern: normalizePatterns(f.Pattern)}
+		}
+
+		qt, err := tt.ff.QtString()
+		if err != nil || qt != tt.qt {
+			t.Errorf("QtString(%v) = %q, %v; want %q", tt.ff, qt, err, tt.qt)
+		}
+		if got, err := ParseQtFilter(qt); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseQtFilter(%q) = %v, %v; want %v", qt, got, err, want)
+		}
+
+		wx, err := tt.ff.WxString()
+		if err != nil || wx != tt.wx {
+			t.Errorf("WxString(%v) = %q, %v; want %q", tt.ff, wx, err, tt.wx)
+		}
+		if got, err := ParseWxFilter(wx); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseWxFilter(%q) = %v, %v; want %v", wx, got, err, want)
+		}
+	}
+}
+
+func TestFormatSeparators(t *testing.T) {
+	tests := []struct {
+		format string
+		ff     FileFilter
+		index  int
+		field  string
+	}{
+		{"qt", FileFilter{{Name: "Text", Pattern: "*.txt"}, {Name: "A;;B", Pattern: "*.a"}}, 1, "name"},
+		{"qt", FileFilter{{Name: "Odd", Pattern: "*.(a)"}}, 0, "pattern"},
+		{"wx", FileFilter{{Name: "Text | logs", Pattern: "*.txt"}}, 0, "name"},
+		{"wx", FileFilter{{Name: "Text", Pattern: "*.txt"}, {Name: "Or", Pattern: "*.a|*.b"}}, 1, "pattern"},
+		{"kdialog", FileFilter{{Name: "Text | logs", Pattern: "*.txt"}}, 0, "name"},
+		{"kdialog", FileFilter{{Name: "Or", Pattern: "*.a|*.b"}}, 0, "pattern"},
+		{"kdialog", FileFilter{{Name: "Text", Pattern: "*.txt"}, {Name: "Two\nlines", Pattern: "*.a"}}, 1, "name"},
+		{"zenity", FileFilter{{Name: "Text | logs", Pattern: "*.txt"}}, 0, "name"},
+		{"zenity", FileFilter{{Name: "Text", Pattern: "*.txt"}, {Name: "Or", Pattern: "*.a|*.b"}}, 1, "pattern"},
+	}
+	for _, tt := range tests {
+		var s string
+		var err error
+		switch tt.format {
+		case "qt":
+			s, err = tt.ff.QtString()
+		case "wx":
+			s, err = tt.ff.WxString()
+		case "kdialog":
+			s, err = tt.ff.KDialogString()
+		case "zenity":
+			var args []string
+			args, err = tt.ff.ZenityArgs()
+			s = strings.Join(args, " ")
+		}
+		var fe *FilterError
+		if !errors.As(err, &fe) || fe.Err != ErrSeparator || fe.Index != tt.index || fe.Field != tt.field {
+			t.Errorf("%s %v = %q, %v; want a separator error in filter %d %s", tt.format, tt.ff, s, err, tt.index, tt.field)
+		}
+	}
+
+	// Separators of other formats are fine.
+	ff := FileFilter{{Name: "A;;B", Pattern: "*.(a)"}}
+	if _, err := ff.WxString(); err != nil {
+		t.Errorf("WxString(%v): %v", ff, err)
+	}
+	ff = FileFilter{{Name: "A|B", Pattern: "*.a"}}
+	if _, err := ff.QtString(); err != nil {
+		t.Errorf("QtString(%v): %v", ff, err)
+	}
+}
+
+func TestKDialogString(t *testing.T) {
+	s, err := testFilter.KDialogString()
+	if want := "Text (*.txt)\nImages (*.png *.jpg)"; err != nil || s != want {
+		t.Errorf("KDialogString() = %q, %v; want %q", s, err, want)
+	}
+}
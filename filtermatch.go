@@ -0,0 +1,93 @@
+package winfileask
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Match reports whether name matches the pattern of f the way the Open and
+// Save As dialogs match it. Only the last element of name is compared. The
+// pattern is a list of alternatives separated by semicolons, matched without
+// regard to case, where '*' matches any run of characters, '?' matches
+// exactly one and every other character, including '[', matches itself. As on
+// Windows, "*" and "*.*" match every name, a pattern ending in ".*" also
+// matches the name without an extension, so "readme.*" matches "readme", and
+// a pattern ending in a single '.' matches only names without a dot.
+func (f Filter) Match(name string) bool {
+	if name = baseName(name); name == "" {
+		return false
+	}
+	for _, glob := range f.Globs() {
+		if matchWildcard(glob, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchIndex returns the index of the first Filter of ff that matches name,
+// or -1 if none does.
+func (ff FileFilter) MatchIndex(name string) int {
+	for i, f := range ff {
+		if f.Match(name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// baseName returns the last element of path, which can use either slash or
+// backslash as the separator.
+func baseName(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// matchWildcard matches a single Windows wildcard pattern.
+func matchWildcard(glob, name string) bool {
+	switch {
+	case glob == "*" || glob == "*.*":
+		return true
+	case strings.HasSuffix(glob, ".*") && matchRunes([]rune(glob[:len(glob)-2]), []rune(name)):
+		return true
+	case strings.HasSuffix(glob, ".") && !strings.HasSuffix(glob, ".."):
+		return !strings.Contains(name, ".") && matchRunes([]rune(glob[:len(glob)-1]), []rune(name))
+	}
+	return matchRunes([]rune(glob), []rune(name))
+}
+
+// matchRunes matches name against pattern, backtracking to the last '*' on a
+// mismatch.
+func matchRunes(pattern, name []rune) bool {
+	p, n := 0, 0
+	star, mark := -1, 0
+	for n < len(name) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, n
+			p++
+		case p < len(pattern) && (pattern[p] == '?' || foldEqual(pattern[p], name[n])):
+			p++
+			n++
+		case star >= 0:
+			mark++
+			p, n = star+1, mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// foldEqual reports whether a and b are equal without regard to case.
+func foldEqual(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	return unicode.ToUpper(a) == unicode.ToUpper(b) || unicode.ToLower(a) == unicode.ToLower(b)
+}
@@ -0,0 +1,122 @@
+package winfileask
+
+import "testing"
+
+func TestFilterMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		// Case folding.
+		{"*.txt", "a.txt", true},
+		{"*.txt", "A.TXT", true},
+		{"*.TXT", "a.TxT", true},
+		{"*.ÄÖ", "x.äö", true},
+		{"*.σ", "x.Σ", true},
+		{"*.σ", "x.ς", true},
+		{"straße.*", "STRASSE.txt", false},
+
+		// Only the last element is compared.
+		{"*.txt", "dir/a.txt", true},
+		{"*.txt", `C:\dir.txt\a.doc`, false},
+		{"*.txt", `C:\dir\a.txt`, true},
+		{"*", "dir/", false},
+		{"*", "", false},
+
+		// Alternatives.
+		{"*.png;*.jpg", "x.JPG", true},
+		{"*.png;*.jpg", "x.png", true},
+		{"*.png;*.jpg", "x.gif", false},
+		{" *.png ; *.jpg ", "x.jpg", true},
+		{";;", "x", false},
+
+		// '?' matches exactly one character.
+		{"?.txt", "a.txt", true},
+		{"?.txt", "ab.txt", false},
+		{"?.txt", ".txt", false},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"a?c", "abbc", false},
+		{"??", "😀x", true},
+
+		// '*' matches any run, including an empty one.
+		{"*.txt", ".txt", true},
+		{"*.txt", "a.txt.bak", false},
+		{"*.txt", "txt", false},
+		{"*", "noext", true},
+		{"a*", "a", true},
+		{"*a", "ba", true},
+
+		// "*" and "*.*" match every name, with or without an extension.
+		{"*.*", "noext", true},
+		{"*.*", ".hidden", true},
+		{"*.*", "a.b.c", true},
+
+		// A pattern ending in ".*" also matches the name without extension.
+		{"readme.*", "readme", true},
+		{"readme.*", "README.md", true},
+		{"readme.*", "readme.txt.bak", true},
+		{"readme.*", "readmes", false},
+		{"readme.*", "readme.", true},
+		{"*.*.*", "a.b", true},
+
+		// A pattern ending in a single '.' matches names without a dot.
+		{"name.", "name", true},
+		{"name.", "NAME", true},
+		{"name.", "name.txt", false},
+		{"*.", "noext", true},
+		{"*.", "a.txt", false},
+		{"x..", "x..", true},
+		{"x..", "x", false},
+
+		// Backtracking.
+		{"a*b*c", "aXbYc", true},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "abcbc", true},
+		{"a*b*c", "aXbY", false},
+		{"a*b*c", "acb", false},
+		{"*a*a*a", "aaa", true},
+		{"*a*a*a", "xaxaxa", true},
+		{"*a*a*a", "aa", false},
+		{"*ab", "aab", true},
+		{"*ab", "abab", true},
+		{"*ab", "aba", false},
+		{"*.tar.gz", "x.tar.gz", true},
+		{"*.tar.gz", "x.tar.gz.tar.gz", true},
+		{"*.tar.gz", "x.tgz", false},
+		{"*?*?", "ab", true},
+		{"*?*?", "a", false},
+
+		// Brackets have no special meaning.
+		{"[abc].txt", "[abc].txt", true},
+		{"[abc].txt", "a.txt", false},
+	}
+	for _, tt := range tests {
+		if got := (Filter{Name: "F", Pattern: tt.pattern}).Match(tt.name); got != tt.want {
+			t.Errorf("Filter{%q}.Match(%q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchIndex(t *testing.T) {
+	ff := append(FileFilter{}, testFilter...)
+	ff = append(ff, Filter{Name: "All files", Pattern: "*.*"})
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"a.txt", 0},
+		{"photo.JPG", 1},
+		{"doc.pdf", 2},
+		{"noext", 2},
+	}
+	for _, tt := range tests {
+		if got := ff.MatchIndex(tt.name); got != tt.want {
+			t.Errorf("MatchIndex(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+	if got := testFilter.MatchIndex("doc.pdf"); got != -1 {
+		t.Errorf("MatchIndex without a match = %d, want -1", got)
+	}
+}
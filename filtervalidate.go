@@ -0,0 +1,85 @@
+package winfileask
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The problems reported by FileFilter.Validate, wrapped in a *FilterError.
+var (
+	ErrEmptyName    = errors.New("empty name")
+	ErrEmptyPattern = errors.New("empty pattern")
+	ErrNUL          = errors.New("contains a NUL character")
+	ErrSpace        = errors.New("pattern contains a space")
+	// ErrSeparator is reported by the encoders of text formats, such as
+	// QtString, for a name or pattern containing the separator of the
+	// format, which could not be told apart when parsed back.
+	ErrSeparator = errors.New("contains a separator of the filter format")
+)
+
+// FilterError is a problem with one Filter of a FileFilter.
+type FilterError struct {
+	// Index is the position of the Filter in the FileFilter.
+	Index int
+	// Field is "name" or "pattern".
+	Field string
+	Err   error
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("filter %d: %s: %v", e.Index, e.Field, e.Err)
+}
+
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
+
+// FilterErrors is the list of problems found by FileFilter.Validate.
+type FilterErrors []*FilterError
+
+func (e FilterErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors in e so errors.Is and errors.As can find them.
+func (e FilterErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Validate checks that every Filter of ff can be encoded for the Open and Save
+// As dialogs: names and patterns must not be empty or contain a NUL, and
+// patterns must not contain a space. It returns nil or a FilterErrors listing
+// every problem found, in order, with all the problems of each field.
+func (ff FileFilter) Validate() error {
+	var errs FilterErrors
+	for i, f := range ff {
+		if f.Name == "" {
+			errs = append(errs, &FilterError{i, "name", ErrEmptyName})
+		}
+		if strings.IndexByte(f.Name, 0) != -1 {
+			errs = append(errs, &FilterError{i, "name", ErrNUL})
+		}
+		if f.Pattern == "" {
+			errs = append(errs, &FilterError{i, "pattern", ErrEmptyPattern})
+		}
+		if strings.IndexByte(f.Pattern, 0) != -1 {
+			errs = append(errs, &FilterError{i, "pattern", ErrNUL})
+		}
+		if strings.ContainsRune(f.Pattern, ' ') {
+			errs = append(errs, &FilterError{i, "pattern", ErrSpace})
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
@@ -0,0 +1,78 @@
+package winfileask
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	type problem struct {
+		index int
+		field string
+		err   error
+	}
+	tests := []struct {
+		name string
+		ff   FileFilter
+		want []problem
+	}{
+		{"nil", nil, nil},
+		{"valid", testFilter, nil},
+		{"empty name", FileFilter{{Name: "", Pattern: "*.txt"}}, []problem{{0, "name", ErrEmptyName}}},
+		{"empty pattern", FileFilter{{Name: "Text", Pattern: ""}}, []problem{{0, "pattern", ErrEmptyPattern}}},
+		{"both empty", FileFilter{{}}, []problem{{0, "name", ErrEmptyName}, {0, "pattern", ErrEmptyPattern}}},
+		{"NUL in name", FileFilter{{Name: "Te\x00xt", Pattern: "*.txt"}}, []problem{{0, "name", ErrNUL}}},
+		{"NUL in pattern", FileFilter{{Name: "Text", Pattern: "*.t\x00xt"}}, []problem{{0, "pattern", ErrNUL}}},
+		{"space", FileFilter{{Name: "Text files", Pattern: "*.txt *.log"}}, []problem{{0, "pattern", ErrSpace}}},
+		{"NUL and space in pattern", FileFilter{{Name: "Text", Pattern: "*.t\x00xt *.log"}}, []problem{{0, "pattern", ErrNUL}, {0, "pattern", ErrSpace}}},
+		{"bad name and pattern", FileFilter{{Name: "Te\x00xt", Pattern: "*.t\x00xt *.log"}}, []problem{{0, "name", ErrNUL}, {0, "pattern", ErrNUL}, {0, "pattern", ErrSpace}}},
+		{
+			"several filters",
+			FileFilter{
+				{Name: "Text", Pattern: "*.txt"},
+				{Name: "", Pattern: "*.a"},
+				{Name: "Images", Pattern: "*.png;*.jpg"},
+				{Name: "B", Pattern: "*.b *.c"},
+				{Name: "C\x00", Pattern: ""},
+			},
+			[]problem{{1, "name", ErrEmptyName}, {3, "pattern", ErrSpace}, {4, "name", ErrNUL}, {4, "pattern", ErrEmptyPattern}},
+		},
+	}
+	for _, tt := range tests {
+		err := tt.ff.Validate()
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: Validate() = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		var errs FilterErrors
+		if !errors.As(err, &errs) || len(errs) != len(tt.want) {
+			t.Errorf("%s: Validate() = %v, want %d problems", tt.name, err, len(tt.want))
+			continue
+		}
+		for i, w := range tt.want {
+			if e := errs[i]; e.Index != w.index || e.Field != w.field || e.Err != w.err {
+				t.Errorf("%s: problem %d = filter %d %s: %v; want filter %d %s: %v", tt.name, i, e.Index, e.Field, e.Err, w.index, w.field, w.err)
+			}
+			if !errors.Is(err, w.err) {
+				t.Errorf("%s: errors.Is(err, %v) = false", tt.name, w.err)
+			}
+		}
+		if _, rerr := tt.ff.ToRaw(); rerr == nil {
+			t.Errorf("%s: ToRaw accepted an invalid filter", tt.name)
+		}
+	}
+}
+
+func TestFilterErrorMessage(t *testing.T) {
+	err := FileFilter{{Name: "", Pattern: "*.a"}, {Name: "B", Pattern: "*.b *.c"}}.Validate()
+	want := "filter 0: name: empty name; filter 1: pattern: pattern contains a space"
+	if err == nil || err.Error() != want {
+		t.Errorf("Validate() = %v, want %s", err, want)
+	}
+	var fe *FilterError
+	if !errors.As(err, &fe) || fe.Index != 0 {
+		t.Errorf("errors.As found %v, want the first problem", fe)
+	}
+}
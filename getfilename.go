@@ -0,0 +1,42 @@
+package winfileask
+
+import "unsafe"
+
+// GetOpenFileName creates an Open dialog box that lets the user specify the
+// drive, directory, and the name of a file or set of files to be opened.
+//
+// If a Dialoger was installed with SetDefault, such as the Mock of package
+// winfileasktest, its Open method answers instead and parentHWND is unused.
+// This works on every operating system, so code calling GetOpenFileName can
+// be tested with the mock anywhere. A cancellation is then reported as no
+// selection, like a canceled dialog box. Without an installed Dialoger, the
+// common dialog box is shown on Windows, and the dialog of the detected
+// default Dialoger elsewhere.
+//
+// New code should call Open, which also applies the checks of Options; the
+// equivalent of this function is
+//
+//	res, err := winfileask.Open(winfileask.Options{
+//		Title:      title,
+//		Filter:     filter,
+//		InitialDir: initialDir,
+//		Flags:      winfileask.OpenFlags,
+//	})
+func GetOpenFileName(parentHWND unsafe.Pointer, title string, filter FileFilter, initialDir string) (string, bool, error) {
+	if d := installedDialoger(); d != nil {
+		return dialogerFileName(d.Open, Options{Title: title, Filter: filter, InitialDir: initialDir, Flags: OpenFlags})
+	}
+	return systemFileName(parentHWND, ModeOpen, title, filter, initialDir)
+}
+
+// GetSaveFileName creates a Save dialog box that lets the user specify the
+// drive, directory, and name of a file to save.
+//
+// As with GetOpenFileName, a Dialoger installed with SetDefault answers
+// instead, through its Save method; new code should call Save with SaveFlags.
+func GetSaveFileName(parentHWND unsafe.Pointer, title string, filter FileFilter, initialDir string) (string, bool, error) {
+	if d := installedDialoger(); d != nil {
+		return dialogerFileName(d.Save, Options{Title: title, Filter: filter, InitialDir: initialDir, Flags: SaveFlags})
+	}
+	return systemFileName(parentHWND, ModeSave, title, filter, initialDir)
+}
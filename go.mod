@@ -0,0 +1,10 @@
+module github.com/kroppt/winfileask
+
+go 1.23.0
+
+require (
+	github.com/godbus/dbus/v5 v5.2.2
+	golang.org/x/term v0.30.0
+)
+
+require golang.org/x/sys v0.31.0 // indirect
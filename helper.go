@@ -0,0 +1,64 @@
+package winfileask
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// helperResponse is the answer of a helper process to a dialog request. The
+// request itself is an Options value.
+type helperResponse struct {
+	Paths       []string `json:",omitempty"`
+	FilterIndex int
+	Canceled    bool   `json:",omitempty"`
+	Error       string `json:",omitempty"`
+}
+
+// ServeHelper reads dialog Options encoded as JSON from r, shows the dialog
+// with b and writes the result encoded as JSON to w. It implements the helper
+// side of the protocol used by the WSL backend; see cmd/winfileask-helper.
+func ServeHelper(r io.Reader, w io.Writer, b Backend) error {
+	var opts Options
+	if err := json.NewDecoder(r).Decode(&opts); err != nil {
+		return err
+	}
+	var resp helperResponse
+	res, err := b.Show(opts)
+	switch {
+	case err == ErrCanceled:
+		resp.Canceled = true
+	case err != nil:
+		resp.Error = err.Error()
+	default:
+		resp.Paths = res.Paths
+		resp.FilterIndex = res.FilterIndex
+	}
+	data, err := marshalASCII(resp)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// marshalASCII encodes v as JSON, escaping every non-ASCII character. Windows
+// processes do not always read their standard input as UTF-8.
+func marshalASCII(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(data))
+	for _, r := range string(data) {
+		if r < 0x80 {
+			out = append(out, byte(r))
+			continue
+		}
+		for _, u := range utf16.Encode([]rune{r}) {
+			out = append(out, fmt.Sprintf(`\u%04x`, u)...)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package winfileask
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LinkPolicy selects what happens to selected paths that go through symbolic
+// links, junctions or other reparse points.
+type LinkPolicy int
+
+const (
+	// LinkKeep returns paths as the dialog reported them.
+	LinkKeep LinkPolicy = iota
+	// LinkResolve replaces paths with their final targets. Result.RawPaths
+	// keeps the paths the dialog reported.
+	LinkResolve
+	// LinkReject fails with a *LinkError if any path goes through a link.
+	LinkReject
+)
+
+// LinkError reports a selected path that goes through a link when
+// Options.Links is LinkReject.
+type LinkError struct {
+	Path string
+	// Target is the path with every link resolved.
+	Target string
+}
+
+func (e *LinkError) Error() string {
+	return fmt.Sprintf("%s: path goes through a link to %s", e.Path, e.Target)
+}
+
+// resolveLinks returns path with every link resolved, and whether there was
+// any. The part of path that does not exist yet, as for a new file in a save
+// dialog, is kept as it is. Only symbolic links, junctions and mount points
+// count as links; other reparse points, such as OneDrive placeholders, do not.
+func resolveLinks(path string) (string, bool, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false, err
+	}
+	dir, rest := existingPrefix(abs)
+	if dir == "" {
+		return abs, false, nil
+	}
+	linked := false
+	for p := dir; ; {
+		var fi os.FileInfo
+		if fi, err = os.Lstat(p); err != nil {
+			return "", false, err
+		}
+		if isLink(p, fi) {
+			linked = true
+			break
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			break
+		}
+		p = parent
+	}
+	if !linked {
+		return abs, false, nil
+	}
+	var target string
+	if target, err = finalPath(dir); err != nil {
+		return "", false, err
+	}
+	target = filepath.Join(target, rest)
+	// A link to where it is, such as a junction to its own volume root,
+	// changes nothing.
+	if target == abs {
+		return abs, false, nil
+	}
+	return target, true, nil
+}
+
+// existingPrefix splits the absolute path abs into the longest leading part
+// that exists and the rest. dir is empty if no part exists.
+func existingPrefix(abs string) (dir, rest string) {
+	dir = abs
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			return dir, rest
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", abs
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
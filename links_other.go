@@ -0,0 +1,18 @@
+//go:build !windows
+
+package winfileask
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// isLink reports whether path, described by fi, is a symbolic link.
+func isLink(path string, fi os.FileInfo) bool {
+	return fi.Mode()&os.ModeSymlink != 0
+}
+
+// finalPath returns the existing path with every symbolic link resolved.
+func finalPath(path string) (string, error) {
+	return filepath.EvalSymlinks(path)
+}
@@ -0,0 +1,112 @@
+package winfileask
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// linkTree makes a tree with makeTree, and then a symbolic link for each
+// pair of name and target in links. It returns the tree with its own links
+// resolved, so that only the links made here count.
+func linkTree(t *testing.T, links map[string]string, paths ...string) string {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(makeTree(t, paths...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, target := range links {
+		if err = os.Symlink(filepath.FromSlash(target), filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Skip(err)
+		}
+	}
+	return dir
+}
+
+func TestResolveLinks(t *testing.T) {
+	dir := linkTree(t, map[string]string{
+		"link":         "real",
+		"file.lnk.txt": "real/a.txt",
+		"real/self":    ".",
+		"dangling":     "gone",
+	}, "real/a.txt", "real/sub/b.txt")
+	tests := []struct {
+		path   string
+		want   string
+		linked bool
+	}{
+		{"real/a.txt", "real/a.txt", false},
+		{"real/new.txt", "real/new.txt", false},
+		{"missing/dir/new.txt", "missing/dir/new.txt", false},
+		{"link/a.txt", "real/a.txt", true},
+		{"link/sub/b.txt", "real/sub/b.txt", true},
+		{"link/new.txt", "real/new.txt", true},
+		{"link/newdir/new.txt", "real/newdir/new.txt", true},
+		{"link", "real", true},
+		{"file.lnk.txt", "real/a.txt", true},
+		{"real/self/a.txt", "real/a.txt", true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, filepath.FromSlash(tt.path))
+		want := filepath.Join(dir, filepath.FromSlash(tt.want))
+		got, linked, err := resolveLinks(path)
+		if err != nil || got != want || linked != tt.linked {
+			t.Errorf("resolveLinks(%q) = %q, %v, %v; want %q, %v", tt.path, got, linked, err, want, tt.linked)
+		}
+		if linked && got == path {
+			t.Errorf("resolveLinks(%q) reports a link to the path itself", tt.path)
+		}
+	}
+	if got, linked, err := resolveLinks(filepath.Join(dir, "dangling")); err == nil {
+		t.Errorf("dangling link: resolveLinks() = %q, %v; want an error", got, linked)
+	}
+}
+
+func TestResolveLinksRelative(t *testing.T) {
+	dir := linkTree(t, map[string]string{"link": "real"}, "real/a.txt")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	got, linked, err := resolveLinks(filepath.Join("link", "a.txt"))
+	if want := filepath.Join(dir, "real", "a.txt"); err != nil || !linked || got != want {
+		t.Errorf("resolveLinks() = %q, %v, %v; want %q, true", got, linked, err, want)
+	}
+}
+
+func TestLinkPolicy(t *testing.T) {
+	dir := linkTree(t, map[string]string{"link": "real"}, "real/a.txt", "real/b.txt")
+	linked := filepath.Join(dir, "link", "a.txt")
+	plain := filepath.Join(dir, "real", "b.txt")
+	d := NewDialoger(backendFunc(func(Options) (*Result, error) {
+		return &Result{Paths: []string{plain, linked}, FilterIndex: -1}, nil
+	}))
+
+	res, err := d.OpenMultiple(Options{})
+	if err != nil || !reflect.DeepEqual(res.Paths, []string{plain, linked}) || res.RawPaths != nil {
+		t.Errorf("LinkKeep: %+v, %v", res, err)
+	}
+
+	res, err = d.OpenMultiple(Options{Links: LinkResolve})
+	want := []string{plain, filepath.Join(dir, "real", "a.txt")}
+	if err != nil || !reflect.DeepEqual(res.Paths, want) || !reflect.DeepEqual(res.RawPaths, []string{plain, linked}) {
+		t.Errorf("LinkResolve: %+v, %v; want paths %q", res, err, want)
+	}
+
+	_, err = d.OpenMultiple(Options{Links: LinkReject})
+	if le, ok := err.(*LinkError); !ok || le.Path != linked || le.Target != want[1] {
+		t.Errorf("LinkReject: err = %v, want a *LinkError for %q to %q", err, linked, want[1])
+	}
+
+	d = NewDialoger(backendFunc(func(Options) (*Result, error) {
+		return &Result{Paths: []string{plain}, FilterIndex: -1}, nil
+	}))
+	if _, err = d.Open(Options{Links: LinkReject}); err != nil {
+		t.Errorf("LinkReject without links: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package winfileask
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32                   = syscall.NewLazyDLL("kernel32.dll")
+	procGetFinalPathNameByHandleW = modkernel32.NewProc("GetFinalPathNameByHandleW")
+)
+
+// reparseTagNameSurrogate is the bit of a reparse tag that marks a reparse
+// point standing for another named file or folder, as symbolic links,
+// junctions and mount points do.
+const reparseTagNameSurrogate = 0x20000000
+
+// isLink reports whether path, described by fi, is a symbolic link,
+// junction or mount point. Since Go 1.23, os.Lstat reports junctions and
+// placeholders of cloud files alike as os.ModeIrregular, so the reparse tag
+// tells them apart.
+func isLink(path string, fi os.FileInfo) bool {
+	if fi.Mode()&(os.ModeSymlink|os.ModeIrregular) == 0 {
+		return false
+	}
+	tag, err := reparseTag(path)
+	if err != nil {
+		return fi.Mode()&os.ModeSymlink != 0
+	}
+	return tag&reparseTagNameSurrogate != 0
+}
+
+// reparseTag returns the reparse tag of path, or 0 if path is not a reparse
+// point.
+func reparseTag(path string) (uint32, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var data syscall.Win32finddata
+	var h syscall.Handle
+	if h, err = syscall.FindFirstFile(p, &data); err != nil {
+		return 0, err
+	}
+	syscall.FindClose(h)
+	if data.FileAttributes&syscall.FILE_ATTRIBUTE_REPARSE_POINT == 0 {
+		return 0, nil
+	}
+	return data.Reserved0, nil
+}
+
+// finalPath returns the existing path with every symbolic link, junction and
+// mount point resolved, as GetFinalPathNameByHandleW reports it. Unlike
+// filepath.EvalSymlinks, it follows junctions and mount points too.
+func finalPath(path string) (string, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return "", err
+	}
+	var h syscall.Handle
+	h, err = syscall.CreateFile(p, 0,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return "", &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	defer syscall.CloseHandle(h)
+	buf := make([]uint16, syscall.MAX_PATH)
+	for {
+		n, _, err := procGetFinalPathNameByHandleW.Call(uintptr(h), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), 0)
+		if n == 0 {
+			return "", &os.PathError{Op: "GetFinalPathNameByHandle", Path: path, Err: err}
+		}
+		if int(n) < len(buf) {
+			return dosPath(syscall.UTF16ToString(buf[:n]))
+		}
+		buf = make([]uint16, n)
+	}
+}
+
+// dosPath strips the \\?\ prefix that GetFinalPathNameByHandleW puts before
+// the paths it returns.
+func dosPath(path string) (string, error) {
+	switch {
+	case strings.HasPrefix(path, `\\?\UNC\`):
+		return `\\` + path[len(`\\?\UNC\`):], nil
+	case len(path) >= 6 && strings.HasPrefix(path, `\\?\`) && path[5] == ':':
+		return path[len(`\\?\`):], nil
+	}
+	return "", errors.New(path + ": folder has no drive letter")
+}
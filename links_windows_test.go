@@ -0,0 +1,44 @@
+package winfileask
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestDOSPath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`\\?\C:\Users\u`, `C:\Users\u`},
+		{`\\?\C:\`, `C:\`},
+		{`\\?\UNC\server\share\dir`, `\\server\share\dir`},
+	}
+	for _, tt := range tests {
+		if got, err := dosPath(tt.in); err != nil || got != tt.want {
+			t.Errorf("dosPath(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if got, err := dosPath(`\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\dir`); err == nil {
+		t.Errorf("dosPath of a volume GUID path = %q, want an error", got)
+	}
+}
+
+func TestResolveJunction(t *testing.T) {
+	dir, err := finalPath(makeTree(t, "real/a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	junction := filepath.Join(dir, "junction")
+	if out, err := exec.Command("cmd", "/c", "mklink", "/J", junction, filepath.Join(dir, "real")).CombinedOutput(); err != nil {
+		t.Skipf("mklink /J: %v: %s", err, out)
+	}
+	path := filepath.Join(junction, "a.txt")
+	got, linked, err := resolveLinks(path)
+	if want := filepath.Join(dir, "real", "a.txt"); err != nil || !linked || got != want {
+		t.Errorf("resolveLinks(%q) = %q, %v, %v; want %q, true", path, got, linked, err, want)
+	}
+	if got, linked, err = resolveLinks(filepath.Join(dir, "real", "a.txt")); err != nil || linked {
+		t.Errorf("resolveLinks without a junction = %q, %v, %v", got, linked, err)
+	}
+}
@@ -0,0 +1,349 @@
+// Package lnk reads Windows shortcut (.lnk) files in the Shell Link Binary
+// File Format described in [MS-SHLLINK]. It is pure Go and does not resolve
+// anything through the shell, so it works the same on every platform.
+//
+// Strings stored in the system code page rather than in UTF-16 are decoded as
+// Latin-1, which is exact for ASCII and close for Windows-1252.
+//
+// [MS-SHLLINK]: https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-shllink
+package lnk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrNotShellLink is returned for data that does not start with a
+// ShellLinkHeader.
+var ErrNotShellLink = errors.New("lnk: not a shell link")
+
+// ErrTruncated is returned when a structure extends past the end of the data.
+var ErrTruncated = errors.New("lnk: truncated shell link")
+
+// Link holds the parts of a shell link that locate its target.
+type Link struct {
+	// Target is the path of the target, such as C:\dir\file.txt or
+	// \\server\share\file.txt, taken from the LinkInfo structure or from the
+	// environment variable block. A path from the environment variable
+	// block is not expanded, so it may contain environment variables, as in
+	// %USERPROFILE%\file.txt, and is only absolute once they are. Target is
+	// empty if the link only has an ID list.
+	Target string
+	// RelativePath is the location of the target relative to the .lnk
+	// file, such as ..\dir\file.txt.
+	RelativePath string
+	// WorkingDir is the directory the target is started in.
+	WorkingDir string
+	// Arguments are the command line arguments of the target.
+	Arguments string
+	// Name is the description of the link.
+	Name string
+	// IconLocation is the file holding the icon of the link.
+	IconLocation string
+}
+
+// The LinkFlags used by Parse.
+const (
+	hasLinkTargetIDList = 1 << iota
+	hasLinkInfo
+	hasName
+	hasRelativePath
+	hasWorkingDir
+	hasArguments
+	hasIconLocation
+	isUnicode
+	forceNoLinkInfo
+	hasExpString
+)
+
+// The LinkInfoFlags.
+const (
+	volumeIDAndLocalBasePath               = 1
+	commonNetworkRelativeLinkAndPathSuffix = 2
+)
+
+const (
+	headerSize               = 0x4C
+	environmentVariableBlock = 0xA0000001
+)
+
+// linkCLSID is 00021401-0000-0000-C000-000000000046 in its binary form.
+var linkCLSID = []byte{
+	0x01, 0x14, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46,
+}
+
+// Open reads the shell link in the named file.
+func Open(name string) (*Link, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Read reads a shell link from r.
+func Read(r io.Reader) (*Link, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a shell link.
+func Parse(data []byte) (*Link, error) {
+	if len(data) < headerSize || binary.LittleEndian.Uint32(data) != headerSize || !bytes.Equal(data[4:20], linkCLSID) {
+		return nil, ErrNotShellLink
+	}
+	flags := binary.LittleEndian.Uint32(data[0x14:])
+	p := &parser{data: data, off: headerSize}
+	if flags&hasLinkTargetIDList != 0 {
+		n, err := p.uint16()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.skip(int(n)); err != nil {
+			return nil, err
+		}
+	}
+	var link Link
+	if flags&hasLinkInfo != 0 {
+		size, err := p.uint32At(p.off)
+		if err != nil {
+			return nil, err
+		}
+		info, err := p.slice(p.off, int(size))
+		if err != nil {
+			return nil, err
+		}
+		if flags&forceNoLinkInfo == 0 {
+			if link.Target, err = parseLinkInfo(info); err != nil {
+				return nil, err
+			}
+		}
+		p.off += int(size)
+	}
+	unicode := flags&isUnicode != 0
+	for _, s := range []struct {
+		flag uint32
+		dst  *string
+	}{
+		{hasName, &link.Name},
+		{hasRelativePath, &link.RelativePath},
+		{hasWorkingDir, &link.WorkingDir},
+		{hasArguments, &link.Arguments},
+		{hasIconLocation, &link.IconLocation},
+	} {
+		if flags&s.flag == 0 {
+			continue
+		}
+		var err error
+		if *s.dst, err = p.stringData(unicode); err != nil {
+			return nil, err
+		}
+	}
+	if link.Target == "" && flags&hasExpString != 0 {
+		link.Target = p.environmentTarget()
+	}
+	return &link, nil
+}
+
+// parseLinkInfo returns the target path stored in a LinkInfo structure.
+func parseLinkInfo(info []byte) (string, error) {
+	p := &parser{data: info}
+	if len(info) < 0x1C {
+		return "", ErrTruncated
+	}
+	headerSize := binary.LittleEndian.Uint32(info[4:])
+	flags := binary.LittleEndian.Uint32(info[8:])
+	localOff := int(binary.LittleEndian.Uint32(info[0x10:]))
+	networkOff := int(binary.LittleEndian.Uint32(info[0x14:]))
+	suffixOff := int(binary.LittleEndian.Uint32(info[0x18:]))
+	var base, suffix string
+	var err error
+	if headerSize >= 0x24 && len(info) >= 0x24 {
+		if off := int(binary.LittleEndian.Uint32(info[0x1C:])); off != 0 && flags&volumeIDAndLocalBasePath != 0 {
+			if base, err = p.cStringUTF16(off); err != nil {
+				return "", err
+			}
+		}
+		if off := int(binary.LittleEndian.Uint32(info[0x20:])); off != 0 {
+			if suffix, err = p.cStringUTF16(off); err != nil {
+				return "", err
+			}
+		}
+	}
+	if base == "" && flags&volumeIDAndLocalBasePath != 0 {
+		if base, err = p.cString(localOff); err != nil {
+			return "", err
+		}
+	}
+	if base == "" && flags&commonNetworkRelativeLinkAndPathSuffix != 0 {
+		if base, err = parseNetworkLink(p, networkOff); err != nil {
+			return "", err
+		}
+	}
+	if suffix == "" && suffixOff != 0 {
+		if suffix, err = p.cString(suffixOff); err != nil {
+			return "", err
+		}
+	}
+	if base == "" {
+		return "", nil
+	}
+	if suffix != "" && !strings.HasSuffix(base, `\`) {
+		base += `\`
+	}
+	return base + suffix, nil
+}
+
+// parseNetworkLink returns the share name of the CommonNetworkRelativeLink
+// structure at off in p.
+func parseNetworkLink(p *parser, off int) (string, error) {
+	link, err := p.slice(off, 0x14)
+	if err != nil {
+		return "", err
+	}
+	netNameOff := int(binary.LittleEndian.Uint32(link[8:]))
+	if netNameOff > 0x14 {
+		if link, err = p.slice(off, 0x1C); err != nil {
+			return "", err
+		}
+		if u := int(binary.LittleEndian.Uint32(link[0x14:])); u != 0 {
+			return p.cStringUTF16(off + u)
+		}
+	}
+	return p.cString(off + netNameOff)
+}
+
+// parser reads little-endian structures from data.
+type parser struct {
+	data []byte
+	off  int
+}
+
+func (p *parser) slice(off, n int) ([]byte, error) {
+	if off < 0 || n < 0 || off+n > len(p.data) {
+		return nil, ErrTruncated
+	}
+	return p.data[off : off+n], nil
+}
+
+func (p *parser) skip(n int) error {
+	if _, err := p.slice(p.off, n); err != nil {
+		return err
+	}
+	p.off += n
+	return nil
+}
+
+func (p *parser) uint16() (uint16, error) {
+	b, err := p.slice(p.off, 2)
+	if err != nil {
+		return 0, err
+	}
+	p.off += 2
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (p *parser) uint32At(off int) (uint32, error) {
+	b, err := p.slice(off, 4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// stringData reads a StringData structure: a character count followed by
+// that many characters.
+func (p *parser) stringData(unicode bool) (string, error) {
+	n, err := p.uint16()
+	if err != nil {
+		return "", err
+	}
+	size := int(n)
+	if unicode {
+		size *= 2
+	}
+	b, err := p.slice(p.off, size)
+	if err != nil {
+		return "", err
+	}
+	p.off += size
+	if unicode {
+		return decodeUTF16(b), nil
+	}
+	return decodeLatin1(b), nil
+}
+
+// cString reads a NUL terminated string in the system code page at off.
+func (p *parser) cString(off int) (string, error) {
+	if off < 0 || off >= len(p.data) {
+		return "", ErrTruncated
+	}
+	end := bytes.IndexByte(p.data[off:], 0)
+	if end < 0 {
+		return "", ErrTruncated
+	}
+	return decodeLatin1(p.data[off : off+end]), nil
+}
+
+// cStringUTF16 reads a NUL terminated UTF-16 string at off.
+func (p *parser) cStringUTF16(off int) (string, error) {
+	if off < 0 {
+		return "", ErrTruncated
+	}
+	for end := off; end+1 < len(p.data); end += 2 {
+		if p.data[end] == 0 && p.data[end+1] == 0 {
+			return decodeUTF16(p.data[off:end]), nil
+		}
+	}
+	return "", ErrTruncated
+}
+
+// environmentTarget returns the target of the EnvironmentVariableDataBlock
+// among the extra data blocks following p.off, or "" if there is none. The
+// path usually contains environment variables such as %USERPROFILE%.
+func (p *parser) environmentTarget() string {
+	for off := p.off; ; {
+		size, err := p.uint32At(off)
+		if err != nil || size < 8 {
+			return ""
+		}
+		block, err := p.slice(off, int(size))
+		if err != nil {
+			return ""
+		}
+		if binary.LittleEndian.Uint32(block[4:]) == environmentVariableBlock && len(block) >= 0x314 {
+			q := &parser{data: block}
+			if s, err := q.cStringUTF16(0x108); err == nil && s != "" {
+				return s
+			}
+			s, _ := q.cString(8)
+			return s
+		}
+		off += int(size)
+	}
+}
+
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
+
+func decodeLatin1(b []byte) string {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
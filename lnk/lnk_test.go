@@ -0,0 +1,119 @@
+package lnk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		file string
+		want Link
+	}{
+		{"local.lnk", Link{
+			Target:       `C:\Users\Public\Documents\report.txt`,
+			RelativePath: `..\Documents\report.txt`,
+			WorkingDir:   `C:\Users\Public\Documents`,
+			Arguments:    "/print",
+			Name:         "Café report",
+			IconLocation: `%SystemRoot%\system32\shell32.dll`,
+		}},
+		{"unc.lnk", Link{
+			Target:       `\\server\share\dir\file.txt`,
+			RelativePath: `..\share\dir\file.txt`,
+		}},
+		{"unicode.lnk", Link{
+			Target: `C:\Users\Zoë\Документы\файл ✓.txt`,
+			Name:   "Zoë ✓ 😀",
+		}},
+		{"envblock.lnk", Link{Target: `%USERPROFILE%\Desktop\notes.txt`}},
+		{"idlist.lnk", Link{}},
+		{"forcenolinkinfo.lnk", Link{RelativePath: `ignored\rel.txt`}},
+	}
+	for _, tt := range tests {
+		link, err := Open(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Errorf("%s: %v", tt.file, err)
+			continue
+		}
+		if !reflect.DeepEqual(*link, tt.want) {
+			t.Errorf("%s = %+v, want %+v", tt.file, *link, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	local, err := os.ReadFile(filepath.Join("testdata", "local.lnk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated, err := os.ReadFile(filepath.Join("testdata", "truncated.lnk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	badCLSID := bytes.Clone(local)
+	badCLSID[4] ^= 0xFF
+	// A LinkInfo size that runs past the end of the data.
+	bigInfo := bytes.Clone(local)
+	infoOff := headerSize + 2 + int(binary.LittleEndian.Uint16(bigInfo[headerSize:]))
+	bigInfo[infoOff+2] = 0x7F
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, ErrNotShellLink},
+		{"text", []byte("[InternetShortcut]\r\nURL=https://example.com/\r\n"), ErrNotShellLink},
+		{"short header", local[:headerSize-1], ErrNotShellLink},
+		{"bad CLSID", badCLSID, ErrNotShellLink},
+		{"truncated file", truncated, ErrTruncated},
+		{"truncated ID list", local[:headerSize+10], ErrTruncated},
+		{"truncated strings", local[:len(local)-12], ErrTruncated},
+		{"LinkInfo too large", bigInfo, ErrTruncated},
+	}
+	for _, tt := range tests {
+		if link, err := Parse(tt.data); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Parse() = %+v, %v; want %v", tt.name, link, err, tt.err)
+		}
+	}
+	if _, err = Open(filepath.Join("testdata", "missing.lnk")); !os.IsNotExist(err) {
+		t.Errorf("missing file: err = %v", err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "unc.lnk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, err := Read(bytes.NewReader(data))
+	if err != nil || link.Target != `\\server\share\dir\file.txt` {
+		t.Errorf("Read() = %+v, %v", link, err)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	files, _ := filepath.Glob(filepath.Join("testdata", "*.lnk"))
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		link, err := Parse(data)
+		if err == nil && link == nil {
+			t.Fatal("Parse returned neither a link nor an error")
+		}
+		if err != nil && err != ErrNotShellLink && err != ErrTruncated {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package winfileask
+
+// longPathName returns path unchanged because 8.3 short names exist only on
+// Windows.
+func longPathName(path string) string {
+	return path
+}
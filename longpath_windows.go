@@ -0,0 +1,23 @@
+package winfileask
+
+import "syscall"
+
+// longPathName expands the 8.3 short names in path, such as PROGRA~1, with
+// GetLongPathNameW. path must exist; otherwise it is returned unchanged.
+func longPathName(path string) string {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return path
+	}
+	buf := make([]uint16, syscall.MAX_PATH)
+	for {
+		n, err := syscall.GetLongPathName(p, &buf[0], uint32(len(buf)))
+		if err != nil || n == 0 {
+			return path
+		}
+		if int(n) <= len(buf) {
+			return syscall.UTF16ToString(buf[:n])
+		}
+		buf = make([]uint16, n)
+	}
+}
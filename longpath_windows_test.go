@@ -0,0 +1,63 @@
+package winfileask
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// shortPathName returns the 8.3 form of the existing path.
+func shortPathName(t *testing.T, path string) string {
+	t.Helper()
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]uint16, syscall.MAX_PATH)
+	n, err := syscall.GetShortPathName(p, &buf[0], uint32(len(buf)))
+	if err != nil || n == 0 || int(n) > len(buf) {
+		t.Skipf("GetShortPathName(%q): %v", path, err)
+	}
+	return syscall.UTF16ToString(buf[:n])
+}
+
+func TestLongPathName(t *testing.T) {
+	dir, err := finalPath(makeTree(t, "Long Folder Name/Report for 2024.txt", "Long Folder Name/Data table.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := filepath.Join(dir, "Long Folder Name", "Report for 2024.txt")
+	short := shortPathName(t, long)
+	if short == long || !strings.Contains(short, "~") {
+		t.Skipf("no 8.3 name for %q; short names may be disabled", long)
+	}
+	if got := longPathName(short); got != long {
+		t.Errorf("longPathName(%q) = %q, want %q", short, got, long)
+	}
+	missing := filepath.Join(dir, "missing", "x.txt")
+	if got := longPathName(missing); got != missing {
+		t.Errorf("longPathName of a missing path = %q, want it unchanged", got)
+	}
+
+	// An old-style multiple selection comes back as short names.
+	shortDir := shortPathName(t, filepath.Join(dir, "Long Folder Name"))
+	names := []string{
+		filepath.Base(short),
+		filepath.Base(shortPathName(t, filepath.Join(dir, "Long Folder Name", "Data table.csv"))),
+	}
+	file := shortDir + " " + strings.Join(names, " ")
+	dlg := &fakeComdlg{file: file, offset: uint16(len(shortDir) + 1)}
+	res, err := showFileDialog(dlg, nil, Options{Flags: OpenFlags | AllowMultiSelect | NoLongNames})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{long, filepath.Join(dir, "Long Folder Name", "Data table.csv")}
+	if len(res.Paths) != 2 || res.Paths[0] != want[0] || res.Paths[1] != want[1] {
+		t.Errorf("paths = %q, want %q", res.Paths, want)
+	}
+	if _, err = os.Stat(res.Paths[0]); err != nil {
+		t.Error(err)
+	}
+}
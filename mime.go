@@ -0,0 +1,260 @@
+package winfileask
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// MIMEDatabase maps MIME types to descriptions and glob patterns, as read
+// from the freedesktop.org shared-mime-info database.
+type MIMEDatabase struct {
+	types map[string]*mimeInfo
+}
+
+type mimeInfo struct {
+	comment string
+	globs   []string
+}
+
+// The parts of the shared-mime-info XML format used by MIMEDatabase.
+type xmlMIMEInfo struct {
+	Types []struct {
+		Type     string `xml:"type,attr"`
+		Comments []struct {
+			Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"comment"`
+		Globs []struct {
+			Pattern string `xml:"pattern,attr"`
+		} `xml:"glob"`
+	} `xml:"mime-type"`
+}
+
+// ParseSharedMIMEInfo reads a shared-mime-info XML file, such as
+// /usr/share/mime/packages/freedesktop.org.xml, into db. Types already in db
+// are given the comment of the file and the globs of both. If db is nil, a new
+// database is returned.
+func ParseSharedMIMEInfo(db *MIMEDatabase, r io.Reader) (*MIMEDatabase, error) {
+	var doc xmlMIMEInfo
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if db == nil {
+		db = &MIMEDatabase{}
+	}
+	if db.types == nil {
+		db.types = make(map[string]*mimeInfo)
+	}
+	for _, t := range doc.Types {
+		name := strings.ToLower(t.Type)
+		info := db.types[name]
+		if info == nil {
+			info = &mimeInfo{}
+			db.types[name] = info
+		}
+		for _, c := range t.Comments {
+			if c.Lang == "" {
+				info.comment = strings.TrimSpace(c.Text)
+			}
+		}
+		for _, g := range t.Globs {
+			info.globs = appendGlob(info.globs, g.Pattern)
+		}
+	}
+	return db, nil
+}
+
+// LoadSharedMIMEInfo reads the package files of the shared-mime-info
+// databases in XDG_DATA_HOME and XDG_DATA_DIRS. Files that cannot be read or
+// parsed are skipped. It fails if no file is read, which is the case on
+// systems other than Linux and the BSDs.
+func LoadSharedMIMEInfo() (*MIMEDatabase, error) {
+	home := os.Getenv("XDG_DATA_HOME")
+	if home == "" {
+		if h, err := os.UserHomeDir(); err == nil {
+			home = filepath.Join(h, ".local", "share")
+		}
+	}
+	dirs := os.Getenv("XDG_DATA_DIRS")
+	if dirs == "" {
+		dirs = "/usr/local/share:/usr/share"
+	}
+	// Directories listed first take precedence, so they are read last.
+	list := filepath.SplitList(dirs)
+	list = append([]string{home}, list...)
+	var db *MIMEDatabase
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i] == "" {
+			continue
+		}
+		files, _ := filepath.Glob(filepath.Join(list[i], "mime", "packages", "*.xml"))
+		for _, name := range files {
+			f, err := os.Open(name)
+			if err != nil {
+				continue
+			}
+			next, err := ParseSharedMIMEInfo(db, f)
+			f.Close()
+			if err != nil {
+				// A broken package file should not hide the others.
+				continue
+			}
+			db = next
+		}
+	}
+	if db == nil {
+		return nil, fmt.Errorf("no shared-mime-info database found")
+	}
+	return db, nil
+}
+
+// Filter returns a Filter for mimeType, which is either a type such as
+// "image/png" or a media range such as "image/*". The name is the description
+// of the type from the database.
+func (db *MIMEDatabase) Filter(mimeType string) (Filter, error) {
+	mimeType = baseType(mimeType)
+	if major, ok := mediaRange(mimeType); ok {
+		var names []string
+		for name := range db.types {
+			if strings.HasPrefix(name, major+"/") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		var globs []string
+		for _, name := range names {
+			for _, g := range db.types[name].globs {
+				globs = appendGlob(globs, g)
+			}
+		}
+		return mimeFilter(mimeType, rangeName(major), globs)
+	}
+	info := db.types[mimeType]
+	if info == nil {
+		return Filter{}, fmt.Errorf("unknown MIME type %q", mimeType)
+	}
+	name := info.comment
+	if name == "" {
+		name = typeName(mimeType)
+	}
+	return mimeFilter(mimeType, name, info.globs)
+}
+
+var (
+	systemMIMEOnce sync.Once
+	systemMIME     *MIMEDatabase
+)
+
+// FilterForMIME returns a Filter for mimeType, which is either a type such as
+// "image/png" or a media range such as "image/*". The shared-mime-info
+// database is used if LoadSharedMIMEInfo finds one. For types it does not
+// know, or if there is no database, the extensions come from the mime
+// package, which knows fewer types and has no descriptions.
+func FilterForMIME(mimeType string) (Filter, error) {
+	systemMIMEOnce.Do(func() {
+		systemMIME, _ = LoadSharedMIMEInfo()
+	})
+	if systemMIME != nil {
+		if f, err := systemMIME.Filter(mimeType); err == nil {
+			return f, nil
+		}
+	}
+	return mimePackageFilter(mimeType)
+}
+
+// mimePackageFilter is FilterForMIME using only the mime package.
+func mimePackageFilter(mimeType string) (Filter, error) {
+	mimeType = baseType(mimeType)
+	if major, ok := mediaRange(mimeType); ok {
+		var globs []string
+		for _, ext := range commonExtensions {
+			if t := mime.TypeByExtension(ext); strings.HasPrefix(t, major+"/") {
+				globs = appendGlob(globs, "*"+ext)
+			}
+		}
+		return mimeFilter(mimeType, rangeName(major), globs)
+	}
+	exts, err := mime.ExtensionsByType(mimeType)
+	if err != nil {
+		return Filter{}, err
+	}
+	var globs []string
+	for _, ext := range exts {
+		globs = appendGlob(globs, "*"+ext)
+	}
+	return mimeFilter(mimeType, typeName(mimeType), globs)
+}
+
+// commonExtensions are looked up with mime.TypeByExtension to expand a media
+// range, since the mime package cannot list the types it knows.
+var commonExtensions = []string{
+	".aac", ".avif", ".bmp", ".css", ".csv", ".doc", ".docx", ".flac", ".gif",
+	".gz", ".htm", ".html", ".ico", ".jpeg", ".jpg", ".js", ".json", ".md",
+	".mjs", ".mkv", ".mov", ".mp3", ".mp4", ".mpeg", ".odp", ".ods", ".odt",
+	".oga", ".ogg", ".ogv", ".opus", ".otf", ".pdf", ".png", ".ppt", ".pptx",
+	".rtf", ".svg", ".tar", ".tif", ".tiff", ".ttf", ".txt", ".wasm", ".wav",
+	".weba", ".webm", ".webp", ".woff", ".woff2", ".xls", ".xlsx", ".xml",
+	".zip",
+}
+
+// baseType returns mimeType in lower case without parameters such as
+// "; charset=utf-8".
+func baseType(mimeType string) string {
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// mediaRange reports whether mimeType is a range such as "image/*" and
+// returns its major type.
+func mediaRange(mimeType string) (string, bool) {
+	if !strings.HasSuffix(mimeType, "/*") {
+		return "", false
+	}
+	major := strings.TrimSuffix(mimeType, "/*")
+	return major, major != "" && !strings.Contains(major, "/")
+}
+
+func mimeFilter(mimeType, name string, globs []string) (Filter, error) {
+	if len(globs) == 0 {
+		return Filter{}, fmt.Errorf("no file name patterns for MIME type %q", mimeType)
+	}
+	return Filter{Name: name, Pattern: strings.Join(globs, ";")}, nil
+}
+
+// appendGlob adds glob to globs unless it is already there, ignoring case.
+// Globs that cannot be expressed as a Windows pattern, such as character
+// classes, are dropped.
+func appendGlob(globs []string, glob string) []string {
+	if glob == "" || strings.ContainsAny(glob, "[]; ") {
+		return globs
+	}
+	return dedupeGlobs(globs, []string{glob})
+}
+
+// rangeName returns a name for a media range, such as "Image files".
+func rangeName(major string) string {
+	r := []rune(major)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r) + " files"
+}
+
+// typeName returns a name for a type without a description, such as
+// "PNG files" for "image/png".
+func typeName(mimeType string) string {
+	_, sub, _ := strings.Cut(mimeType, "/")
+	sub = strings.TrimPrefix(sub, "x-")
+	if i := strings.IndexByte(sub, '+'); i > 0 {
+		sub = sub[:i]
+	}
+	return strings.ToUpper(sub) + " files"
+}
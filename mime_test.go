@@ -0,0 +1,161 @@
+package winfileask
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testMIMEXML = `<?xml version="1.0" encoding="UTF-8"?>
+<mime-info xmlns="http://www.freedesktop.org/standards/shared-mime-info">
+  <mime-type type="image/png">
+    <comment>PNG image</comment>
+    <comment xml:lang="de">PNG-Bild</comment>
+    <glob pattern="*.png"/>
+  </mime-type>
+  <mime-type type="image/JPEG">
+    <comment>JPEG image</comment>
+    <glob pattern="*.jpg"/>
+    <glob pattern="*.jpeg"/>
+    <glob pattern="*.JPG"/>
+    <glob pattern="*.[jJ][pP][eE]"/>
+  </mime-type>
+  <mime-type type="text/x-log">
+    <glob pattern="*.log"/>
+    <glob pattern="*.log.1 "/>
+  </mime-type>
+  <mime-type type="text/x-readme">
+    <comment>README document</comment>
+    <glob pattern="README"/>
+  </mime-type>
+  <mime-type type="application/x-nothing">
+    <comment>No globs</comment>
+  </mime-type>
+</mime-info>
+`
+
+func TestParseSharedMIMEInfo(t *testing.T) {
+	db, err := ParseSharedMIMEInfo(nil, strings.NewReader(testMIMEXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		mimeType string
+		want     Filter
+	}{
+		{"image/png", Filter{Name: "PNG image", Pattern: "*.png"}},
+		{"IMAGE/PNG; q=0.9", Filter{Name: "PNG image", Pattern: "*.png"}},
+		{"image/jpeg", Filter{Name: "JPEG image", Pattern: "*.jpg;*.jpeg"}},
+		{"text/x-log", Filter{Name: "LOG files", Pattern: "*.log"}},
+		{"text/x-readme", Filter{Name: "README document", Pattern: "README"}},
+		{"image/*", Filter{Name: "Image files", Pattern: "*.jpg;*.jpeg;*.png"}},
+		{"text/*", Filter{Name: "Text files", Pattern: "*.log;README"}},
+	}
+	for _, tt := range tests {
+		if got, err := db.Filter(tt.mimeType); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Filter(%q) = %v, %v; want %v", tt.mimeType, got, err, tt.want)
+		}
+	}
+	for _, mimeType := range []string{"image/gif", "application/x-nothing", "audio/*", "*/*"} {
+		if f, err := db.Filter(mimeType); err == nil {
+			t.Errorf("Filter(%q) = %v, want an error", mimeType, f)
+		}
+	}
+
+	// A second file adds globs and replaces comments.
+	more := `<mime-info><mime-type type="image/png"><comment>Portable Network Graphics</comment><glob pattern="*.apng"/><glob pattern="*.PNG"/></mime-type></mime-info>`
+	if db, err = ParseSharedMIMEInfo(db, strings.NewReader(more)); err != nil {
+		t.Fatal(err)
+	}
+	want := Filter{Name: "Portable Network Graphics", Pattern: "*.png;*.apng"}
+	if got, err := db.Filter("image/png"); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("merged Filter(image/png) = %v, %v; want %v", got, err, want)
+	}
+
+	if _, err = ParseSharedMIMEInfo(nil, strings.NewReader("<mime-info><mime-type")); err == nil {
+		t.Error("ParseSharedMIMEInfo accepted broken XML")
+	}
+}
+
+// writeMIMEPackage writes a shared-mime-info package file below dataDir.
+func writeMIMEPackage(t *testing.T, dataDir, name, content string) {
+	t.Helper()
+	dir := filepath.Join(dataDir, "mime", "packages")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadSharedMIMEInfo(t *testing.T) {
+	root := t.TempDir()
+	home, first, second := filepath.Join(root, "home"), filepath.Join(root, "first"), filepath.Join(root, "second")
+	writeMIMEPackage(t, second, "freedesktop.org.xml", testMIMEXML)
+	writeMIMEPackage(t, first, "app.xml", `<mime-info><mime-type type="image/png"><comment>First PNG</comment></mime-type></mime-info>`)
+	writeMIMEPackage(t, first, "broken.xml", `<mime-info><mime-type type="image/png">`)
+	writeMIMEPackage(t, home, "user.xml", `<mime-info><mime-type type="text/x-log"><comment>Log file</comment></mime-type></mime-info>`)
+	t.Setenv("XDG_DATA_HOME", home)
+	t.Setenv("XDG_DATA_DIRS", first+string(os.PathListSeparator)+second)
+
+	db, err := LoadSharedMIMEInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		mimeType string
+		want     Filter
+	}{
+		{"image/png", Filter{Name: "First PNG", Pattern: "*.png"}},
+		{"text/x-log", Filter{Name: "Log file", Pattern: "*.log"}},
+		{"image/jpeg", Filter{Name: "JPEG image", Pattern: "*.jpg;*.jpeg"}},
+	}
+	for _, tt := range tests {
+		if got, err := db.Filter(tt.mimeType); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Filter(%q) = %v, %v; want %v", tt.mimeType, got, err, tt.want)
+		}
+	}
+
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "none"))
+	t.Setenv("XDG_DATA_DIRS", filepath.Join(root, "empty"))
+	writeMIMEPackage(t, filepath.Join(root, "empty"), "broken.xml", "not xml")
+	if _, err = LoadSharedMIMEInfo(); err == nil {
+		t.Error("LoadSharedMIMEInfo succeeded without a readable package file")
+	}
+}
+
+func TestFilterForMIMEFallback(t *testing.T) {
+	db, err := ParseSharedMIMEInfo(nil, strings.NewReader(testMIMEXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	systemMIMEOnce.Do(func() {})
+	prev := systemMIME
+	systemMIME = db
+	defer func() { systemMIME = prev }()
+
+	tests := []struct {
+		mimeType string
+		want     Filter
+	}{
+		{"image/png", Filter{Name: "PNG image", Pattern: "*.png"}},
+		// Missing from the database, known to the mime package.
+		{"application/pdf", Filter{Name: "PDF files", Pattern: "*.pdf"}},
+	}
+	for _, tt := range tests {
+		if got, err := FilterForMIME(tt.mimeType); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterForMIME(%q) = %v, %v; want %v", tt.mimeType, got, err, tt.want)
+		}
+	}
+	if f, err := FilterForMIME("application/x-winfileask-unknown"); err == nil {
+		t.Errorf("FilterForMIME of an unknown type = %v, want an error", f)
+	}
+
+	systemMIME = nil
+	if got, err := FilterForMIME("image/png"); err != nil || !(Filter{Name: "F", Pattern: got.Pattern}).Match("x.png") {
+		t.Errorf("FilterForMIME(image/png) without a database = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package winfileask
+
+import "unsafe"
+
+// nativeBackend returns nil because the native dialogs exist only on Windows.
+func nativeBackend() Backend {
+	return nil
+}
+
+// fileOKHook is the hook procedure showFileDialog installs to refuse
+// selections. There is none because comdlg32.dll exists only on Windows.
+const fileOKHook = 0
+
+// systemFileName shows the dialog of GetOpenFileName or GetSaveFileName
+// when no Dialoger is installed. Without comdlg32.dll, the default Dialoger
+// shows it.
+func systemFileName(parentHWND unsafe.Pointer, mode Mode, title string, filter FileFilter, initialDir string) (string, bool, error) {
+	opts := Options{Title: title, Filter: filter, InitialDir: initialDir, Flags: OpenFlags}
+	if mode == ModeSave {
+		opts.Flags = SaveFlags
+		return dialogerFileName(Default().Save, opts)
+	}
+	return dialogerFileName(Default().Open, opts)
+}
@@ -0,0 +1,188 @@
+package winfileask
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modole32                = syscall.NewLazyDLL("ole32.dll")
+	procCoInitializeEx      = modole32.NewProc("CoInitializeEx")
+	procCoUninitialize      = modole32.NewProc("CoUninitialize")
+	procCoTaskMemFree       = modole32.NewProc("CoTaskMemFree")
+	modshell32              = syscall.NewLazyDLL("shell32.dll")
+	procSHBrowseForFolder   = modshell32.NewProc("SHBrowseForFolderW")
+	procSHGetPathFromIDList = modshell32.NewProc("SHGetPathFromIDListW")
+	moduser32               = syscall.NewLazyDLL("user32.dll")
+	procSendMessage         = moduser32.NewProc("SendMessageW")
+	procGetParent           = moduser32.NewProc("GetParent")
+	procMessageBox          = moduser32.NewProc("MessageBoxW")
+	procSetWindowLong       = moduser32.NewProc("SetWindowLongW")
+	procSetWindowLongPtr    = moduser32.NewProc("SetWindowLongPtrW")
+)
+
+const (
+	coinitApartmentThreaded = 0x2
+
+	bifReturnOnlyFSDirs = 0x00000001
+	bifEditBox          = 0x00000010
+	bifNewDialogStyle   = 0x00000040
+
+	bffmInitialized   = 1
+	bffmSelChanged    = 2
+	bffmEnableOK      = 0x0400 + 101
+	bffmSetSelectionW = 0x0400 + 103
+
+	wmNotify      = 0x004E
+	cdnFileOK     = 0xFFFFFDA2 // CDN_FIRST - 5, that is -606
+	dwlpMsgResult = 0
+	mbIconWarning = 0x00000030
+)
+
+// browseInfo is the BROWSEINFOW structure used by SHBrowseForFolder.
+type browseInfo struct {
+	hwndOwner      unsafe.Pointer
+	pidlRoot       uintptr
+	pszDisplayName *uint16
+	lpszTitle      *uint16
+	ulFlags        uint32
+	lpfn           uintptr
+	lParam         uintptr
+	iImage         int32
+}
+
+// browseState is what browseCallback needs for one folder browser.
+type browseState struct {
+	dir   *uint16
+	check func(paths []string) string
+}
+
+// browseCallback selects the initial directory once the folder browser has
+// been created, and disables the OK button for folders that check refuses.
+// Callbacks are never released, so there is only one.
+var browseCallback = syscall.NewCallback(func(hwnd, msg, lParam, lpData uintptr) uintptr {
+	state, _ := lookupHookData(lpData).(*browseState)
+	if state == nil {
+		return 0
+	}
+	switch {
+	case msg == bffmInitialized && state.dir != nil:
+		procSendMessage.Call(hwnd, bffmSetSelectionW, 1, uintptr(unsafe.Pointer(state.dir)))
+	case msg == bffmSelChanged && state.check != nil:
+		buf := make([]uint16, syscall.MAX_PATH)
+		enable := uintptr(0)
+		if ret, _, _ := procSHGetPathFromIDList.Call(lParam, uintptr(unsafe.Pointer(&buf[0]))); ret != 0 &&
+			state.check([]string{syscall.UTF16ToString(buf)}) == "" {
+			enable = 1
+		}
+		procSendMessage.Call(hwnd, bffmEnableOK, 0, enable)
+	}
+	return 0
+})
+
+// ofNotify is the OFNOTIFYW structure that comes with the notifications of
+// Explorer-style dialog boxes.
+type ofNotify struct {
+	hwndFrom uintptr
+	idFrom   uintptr
+	code     uint32
+	lpOFN    *TagOFNA
+	pszFile  *uint16
+}
+
+// fileOKHook is the hook procedure showFileDialog installs to refuse
+// selections. On CDN_FILEOK, it shows why in a message box and keeps the
+// dialog box open.
+var fileOKHook = syscall.NewCallback(func(hwnd, msg, wParam, lParam uintptr) uintptr {
+	if msg != wmNotify || lParam == 0 {
+		return 0
+	}
+	n := *(**ofNotify)(unsafe.Pointer(&lParam))
+	if n.code != cdnFileOK {
+		return 0
+	}
+	text := fileOKMessage(n.lpOFN)
+	if text == "" {
+		return 0
+	}
+	// The hook belongs to a child of the dialog box.
+	dialog, _, _ := procGetParent.Call(hwnd)
+	if p, err := syscall.UTF16PtrFromString(text); err == nil {
+		procMessageBox.Call(dialog, uintptr(unsafe.Pointer(p)), 0, mbIconWarning)
+	}
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		procSetWindowLongPtr.Call(hwnd, dwlpMsgResult, 1)
+	} else {
+		procSetWindowLong.Call(hwnd, dwlpMsgResult, 1)
+	}
+	return 1
+})
+
+// Native is a Backend that shows the common Open and Save As dialog boxes and
+// the shell folder browser.
+type Native struct {
+	// Owner is the window that owns the dialog box. It can be nil if the
+	// dialog box has no owner.
+	Owner unsafe.Pointer
+}
+
+// Show implements Backend.
+func (n Native) Show(opts Options) (*Result, error) {
+	if opts.Mode == ModeFolder {
+		return n.showFolder(opts)
+	}
+	return showFileDialog(comdlg32{}, n.Owner, opts)
+}
+
+func (n Native) showFolder(opts Options) (*Result, error) {
+	// COM is initialized per thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	ret, _, _ := procCoInitializeEx.Call(0, coinitApartmentThreaded)
+	// S_OK and S_FALSE both require a matching CoUninitialize.
+	if int32(ret) >= 0 {
+		defer procCoUninitialize.Call()
+	}
+	var title, dir *uint16
+	var err error
+	if title, err = syscall.UTF16PtrFromString(opts.Title); err != nil {
+		return nil, err
+	}
+	bi := browseInfo{
+		hwndOwner: n.Owner,
+		lpszTitle: title,
+		ulFlags:   bifReturnOnlyFSDirs | bifEditBox | bifNewDialogStyle,
+		lpfn:      browseCallback,
+	}
+	if opts.InitialDir != "" {
+		if dir, err = syscall.UTF16PtrFromString(opts.InitialDir); err != nil {
+			return nil, err
+		}
+	}
+	state := &browseState{dir: dir}
+	if len(opts.AllowedRoots) != 0 {
+		state.check = opts.rootMessage
+	}
+	bi.lParam = addHookData(state)
+	defer removeHookData(bi.lParam)
+	pidl, _, _ := procSHBrowseForFolder.Call(uintptr(unsafe.Pointer(&bi)))
+	if pidl == 0 {
+		return nil, ErrCanceled
+	}
+	defer procCoTaskMemFree.Call(pidl)
+	buf := make([]uint16, syscall.MAX_PATH)
+	if ret, _, _ := procSHGetPathFromIDList.Call(pidl, uintptr(unsafe.Pointer(&buf[0]))); ret == 0 {
+		return nil, fmt.Errorf("selected folder is not a file system directory")
+	}
+	return &Result{
+		Paths:       []string{syscall.UTF16ToString(buf)},
+		FilterIndex: -1,
+	}, nil
+}
+
+// nativeBackend returns the Native backend without an owner window.
+func nativeBackend() Backend {
+	return Native{}
+}
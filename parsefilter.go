@@ -0,0 +1,199 @@
+package winfileask
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// ParseError describes a malformed filter string.
+type ParseError struct {
+	// Input is the filter string being parsed.
+	Input string
+	// Offset is the byte offset in Input where the problem was found.
+	Offset int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("filter %q: offset %d: %s", e.Input, e.Offset, e.Msg)
+}
+
+// ParseQtFilter parses a filter in the format used by Qt's QFileDialog, such
+// as "Images (*.png *.jpg);;Text files (*.txt)". Entries are separated by
+// ";;" and end with their space separated patterns in parentheses. An entry
+// without parentheses is used both as the name and as the patterns. The
+// patterns are joined with semicolons as ToRaw requires.
+func ParseQtFilter(s string) (FileFilter, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var ff FileFilter
+	for start := 0; ; {
+		end := strings.Index(s[start:], ";;")
+		if end < 0 {
+			end = len(s)
+		} else {
+			end += start
+		}
+		f, err := parseQtEntry(s, start, end)
+		if err != nil {
+			return nil, err
+		}
+		ff = append(ff, f)
+		if end == len(s) {
+			return ff, nil
+		}
+		start = end + 2
+	}
+}
+
+// parseQtEntry parses the entry s[start:end].
+func parseQtEntry(s string, start, end int) (Filter, error) {
+	entry := s[start:end]
+	if strings.TrimSpace(entry) == "" {
+		return Filter{}, &ParseError{s, start, "empty filter"}
+	}
+	lp := strings.LastIndexByte(entry, '(')
+	rp := strings.LastIndexByte(entry, ')')
+	switch {
+	case lp < 0 && rp < 0:
+		return Filter{Name: strings.TrimSpace(entry), Pattern: normalizePatterns(entry)}, nil
+	case lp < 0:
+		return Filter{}, &ParseError{s, start + rp, "unexpected ')'"}
+	case rp < lp:
+		return Filter{}, &ParseError{s, start + lp, "unclosed '('"}
+	}
+	if rest := entry[rp+1:]; strings.TrimSpace(rest) != "" {
+		return Filter{}, &ParseError{s, start + rp + 1, "unexpected text after ')'"}
+	}
+	name := strings.TrimSpace(entry[:lp])
+	if name == "" {
+		return Filter{}, &ParseError{s, start, "missing filter name"}
+	}
+	pattern := normalizePatterns(entry[lp+1 : rp])
+	if pattern == "" {
+		return Filter{}, &ParseError{s, start + lp + 1, "empty pattern list"}
+	}
+	return Filter{Name: name, Pattern: pattern}, nil
+}
+
+// ParseWxFilter parses a filter in the format used by wxWidgets' wxFileDialog,
+// such as "Images|*.png;*.jpg|Text files|*.txt". Names and patterns alternate,
+// separated by '|'. A string without any '|' is a single pattern used as its
+// own name. Space separated patterns are joined with semicolons.
+func ParseWxFilter(s string) (FileFilter, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var fields []string
+	var offsets []int
+	for start := 0; ; {
+		i := strings.IndexByte(s[start:], '|')
+		if i < 0 {
+			fields = append(fields, s[start:])
+			offsets = append(offsets, start)
+			break
+		}
+		fields = append(fields, s[start:start+i])
+		offsets = append(offsets, start)
+		start += i + 1
+	}
+	if len(fields) == 1 {
+		pattern := normalizePatterns(s)
+		if pattern == "" {
+			return nil, &ParseError{s, 0, "empty pattern list"}
+		}
+		return FileFilter{{Name: strings.TrimSpace(s), Pattern: pattern}}, nil
+	}
+	if len(fields)%2 != 0 {
+		return nil, &ParseError{s, offsets[len(offsets)-1], "name without a pattern"}
+	}
+	ff := make(FileFilter, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		name := strings.TrimSpace(fields[i])
+		if name == "" {
+			return nil, &ParseError{s, offsets[i], "missing filter name"}
+		}
+		pattern := normalizePatterns(fields[i+1])
+		if pattern == "" {
+			return nil, &ParseError{s, offsets[i+1], "empty pattern list"}
+		}
+		ff = append(ff, Filter{Name: name, Pattern: pattern})
+	}
+	return ff, nil
+}
+
+// normalizePatterns splits a list of patterns separated by spaces or
+// semicolons and joins them with semicolons.
+func normalizePatterns(s string) string {
+	globs := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ';' || r == ' ' || r == '\t'
+	})
+	return strings.Join(globs, ";")
+}
+
+// FileFilterFromUTF16 decodes a filter in the format of the lpstrFilter
+// member of OPENFILENAME, as returned by ToRaw: pairs of NUL terminated names
+// and patterns, ending with an empty string. Only NULs may follow that empty
+// string, and the empty filter is two NULs. An empty name followed by more
+// filters, a name without a pattern and a buffer that ends before the final
+// NUL are reported as a *ParseError whose Input is buf decoded with its NULs.
+func FileFilterFromUTF16(buf []uint16) (FileFilter, error) {
+	s := string(utf16.Decode(buf))
+	var ff FileFilter
+	for start := 0; ; {
+		name, next, ok := nextRawString(s, start)
+		if !ok {
+			return nil, &ParseError{s, len(s), "missing terminating NUL"}
+		}
+		if name == "" {
+			rest := s[next:]
+			switch {
+			case start == 0 && rest == "":
+				return nil, &ParseError{s, len(s), "missing terminating NUL"}
+			case strings.Trim(rest, "\x00") != "":
+				return nil, &ParseError{s, start, "empty name"}
+			}
+			return ff, nil
+		}
+		pattern, end, ok := nextRawString(s, next)
+		switch {
+		case !ok:
+			return nil, &ParseError{s, len(s), "missing terminating NUL"}
+		case pattern == "":
+			return nil, &ParseError{s, start, "name without a pattern"}
+		}
+		ff = append(ff, Filter{Name: name, Pattern: pattern})
+		start = end
+	}
+}
+
+// FileFilterFromRaw is like FileFilterFromUTF16 but reads the filter from p,
+// such as the LpstrFilter of a TagOFNA. The memory p points to must end with
+// two NULs in a row, as it is read up to them.
+func FileFilterFromRaw(p *uint16) (FileFilter, error) {
+	if p == nil {
+		return nil, nil
+	}
+	n := 0
+	for prev := uint16(1); ; n++ {
+		c := *(*uint16)(unsafe.Add(unsafe.Pointer(p), n*2))
+		if c == 0 && prev == 0 {
+			break
+		}
+		prev = c
+	}
+	return FileFilterFromUTF16(unsafe.Slice(p, n+1))
+}
+
+// nextRawString returns the NUL terminated string at s[start:] and the offset
+// after its NUL. It reports false if there is no NUL.
+func nextRawString(s string, start int) (string, int, bool) {
+	i := strings.IndexByte(s[start:], 0)
+	if i < 0 {
+		return "", len(s), false
+	}
+	return s[start : start+i], start + i + 1, true
+}
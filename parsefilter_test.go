@@ -0,0 +1,183 @@
+package winfileask
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+func TestParseQtFilter(t *testing.T) {
+	tests := []struct {
+		in   string
+		want FileFilter
+	}{
+		{"", nil},
+		{"Images (*.png *.jpg);;Text files (*.txt)", FileFilter{{Name: "Images", Pattern: "*.png;*.jpg"}, {Name: "Text files", Pattern: "*.txt"}}},
+		{"All (*)", FileFilter{{Name: "All", Pattern: "*"}}},
+		{"  Text  ( *.txt\t*.log ) ", FileFilter{{Name: "Text", Pattern: "*.txt;*.log"}}},
+		{"Data (v2) (*.dat)", FileFilter{{Name: "Data (v2)", Pattern: "*.dat"}}},
+		{"*.txt", FileFilter{{Name: "*.txt", Pattern: "*.txt"}}},
+		{"*.png *.jpg;;Text (*.txt)", FileFilter{{Name: "*.png *.jpg", Pattern: "*.png;*.jpg"}, {Name: "Text", Pattern: "*.txt"}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseQtFilter(tt.in)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseQtFilter(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseWxFilter(t *testing.T) {
+	tests := []struct {
+		in   string
+		want FileFilter
+	}{
+		{"", nil},
+		{"Images|*.png;*.jpg|Text files|*.txt", FileFilter{{Name: "Images", Pattern: "*.png;*.jpg"}, {Name: "Text files", Pattern: "*.txt"}}},
+		{"Text|*.txt *.log", FileFilter{{Name: "Text", Pattern: "*.txt;*.log"}}},
+		{" Text | *.txt ", FileFilter{{Name: "Text", Pattern: "*.txt"}}},
+		{"*.png *.jpg", FileFilter{{Name: "*.png *.jpg", Pattern: "*.png;*.jpg"}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseWxFilter(tt.in)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseWxFilter(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseErrorOffsets(t *testing.T) {
+	tests := []struct {
+		parse  string
+		in     string
+		offset int
+		msg    string
+	}{
+		{"qt", ";;Text (*.txt)", 0, "empty filter"},
+		{"qt", "Text (*.txt);;", 14, "empty filter"},
+		{"qt", "Text (*.txt);;  ;;B (*.b)", 14, "empty filter"},
+		{"qt", "Text *.txt)", 10, "unexpected ')'"},
+		{"qt", "A (*.a);;Text (*.txt", 14, "unclosed '('"},
+		{"qt", "Text )(", 6, "unclosed '('"},
+		{"qt", "Text (*.txt) x", 12, "unexpected text after ')'"},
+		{"qt", "A (*.a);; (*.b)", 9, "missing filter name"},
+		{"qt", "Text ( )", 6, "empty pattern list"},
+		{"qt", "A (*.a);;Text ()", 15, "empty pattern list"},
+		{"wx", " ; ", 0, "empty pattern list"},
+		{"wx", "Text|*.txt|Images", 11, "name without a pattern"},
+		{"wx", "|*.txt", 0, "missing filter name"},
+		{"wx", "Text|*.txt| |*.png", 11, "missing filter name"},
+		{"wx", "Text|*.txt|Images| ", 18, "empty pattern list"},
+		{"wx", "Text||Images|*.png", 5, "empty pattern list"},
+		{"raw", "Text\x00*.txt", 10, "missing terminating NUL"},
+		{"raw", "Text\x00*.txt\x00", 11, "missing terminating NUL"},
+		{"raw", "Text\x00", 5, "missing terminating NUL"},
+		{"raw", "Text\x00\x00", 0, "name without a pattern"},
+		{"raw", "A\x00*.a\x00B\x00\x00", 6, "name without a pattern"},
+		{"raw", "\x00", 1, "missing terminating NUL"},
+		{"raw", "\x00*.txt\x00\x00", 0, "empty name"},
+		{"raw", "A\x00*.a\x00\x00B\x00*.b\x00\x00", 6, "empty name"},
+		{"raw", "A\x00*.a\x00\x00\x00x", 6, "empty name"},
+	}
+	for _, tt := range tests {
+		var err error
+		switch tt.parse {
+		case "qt":
+			_, err = ParseQtFilter(tt.in)
+		case "wx":
+			_, err = ParseWxFilter(tt.in)
+		case "raw":
+			_, err = FileFilterFromUTF16(utf16.Encode([]rune(tt.in)))
+		}
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s %q: err = %v, want a *ParseError", tt.parse, tt.in, err)
+			continue
+		}
+		if pe.Input != tt.in || pe.Offset != tt.offset || pe.Msg != tt.msg {
+			t.Errorf("%s %q: error at %d %q in %q, want at %d %q", tt.parse, tt.in, pe.Offset, pe.Msg, pe.Input, tt.offset, tt.msg)
+		}
+	}
+}
+
+func TestFileFilterFromUTF16(t *testing.T) {
+	tests := []struct {
+		in   string
+		want FileFilter
+	}{
+		{"\x00\x00", nil},
+		{"\x00\x00\x00\x00", nil},
+		{"Text\x00*.txt\x00\x00", FileFilter{{Name: "Text", Pattern: "*.txt"}}},
+		{"Text\x00*.txt\x00Images\x00*.png;*.jpg\x00\x00", testFilter},
+		// A buffer larger than the filter is padded with NULs.
+		{"Text\x00*.txt\x00\x00\x00\x00\x00", FileFilter{{Name: "Text", Pattern: "*.txt"}}},
+		{"Zoë ✓\x00*.😀\x00\x00", FileFilter{{Name: "Zoë ✓", Pattern: "*.😀"}}},
+	}
+	for _, tt := range tests {
+		got, err := FileFilterFromUTF16(utf16.Encode([]rune(tt.in)))
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FileFilterFromUTF16(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func FuzzFilterRoundTrip(f *testing.F) {
+	f.Add("Text", "*.txt", "", "")
+	f.Add("Images", "*.png;*.jpg", "All files", "*.*")
+	f.Add("Zoë ✓", "*.😀", "x", "?")
+	f.Fuzz(func(t *testing.T, name1, pattern1, name2, pattern2 string) {
+		ff := FileFilter{{Name: name1, Pattern: pattern1}}
+		if name2 != "" || pattern2 != "" {
+			ff = append(ff, Filter{Name: name2, Pattern: pattern2})
+		}
+		for _, s := range []string{name1, pattern1, name2, pattern2} {
+			if !utf8.ValidString(s) {
+				t.Skip("invalid UTF-8 does not survive UTF-16")
+			}
+		}
+		raw, err := ff.ToRaw()
+		if err != nil {
+			if ff.Validate() == nil {
+				t.Fatalf("ToRaw(%q): %v", ff, err)
+			}
+			t.Skip("invalid filter")
+		}
+		got, err := FileFilterFromRaw(raw)
+		if err != nil || !reflect.DeepEqual(got, ff) {
+			t.Errorf("FileFilterFromRaw(ToRaw(%q)) = %q, %v", ff, got, err)
+		}
+	})
+}
+
+func FuzzFileFilterFromUTF16(f *testing.F) {
+	f.Add("Text\x00*.txt\x00\x00")
+	f.Add("\x00\x00")
+	f.Add("\x00*.txt\x00\x00")
+	f.Fuzz(func(t *testing.T, s string) {
+		ff, err := FileFilterFromUTF16(utf16.Encode([]rune(s)))
+		if err != nil {
+			return
+		}
+		if len(ff) == 0 {
+			ff = nil
+		}
+		raw, err := ff.ToRaw()
+		if err != nil {
+			// Decoded patterns may contain spaces, which Validate rejects.
+			return
+		}
+		got, err := FileFilterFromRaw(raw)
+		if err != nil || !reflect.DeepEqual(got, ff) {
+			t.Errorf("%q decoded to %q, which round-trips to %q, %v", s, ff, got, err)
+		}
+	})
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	_, err := ParseQtFilter("Text (*.txt) x")
+	if want := `filter "Text (*.txt) x": offset 12: unexpected text after ')'`; err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %s", err, want)
+	}
+}
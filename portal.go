@@ -0,0 +1,203 @@
+package winfileask
+
+import (
+	"fmt"
+	"math/rand"
+	"net/url"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	portalDest      = "org.freedesktop.portal.Desktop"
+	portalPath      = "/org/freedesktop/portal/desktop"
+	portalInterface = "org.freedesktop.portal.FileChooser"
+	requestSignal   = "org.freedesktop.portal.Request.Response"
+)
+
+// Portal is a Backend that uses the org.freedesktop.portal.FileChooser
+// interface of the XDG Desktop Portal. It works inside Flatpak and other
+// sandboxes that do not allow spawning dialog programs.
+//
+// The portal always asks before overwriting a file, so OverwritePrompt has no
+// effect.
+type Portal struct {
+	// Conn is the connection to the bus the portal is on. If it is nil, the
+	// shared session bus connection is used.
+	Conn *dbus.Conn
+	// ParentWindow identifies the window the dialog belongs to, such as
+	// "x11:1a00004" or "wayland:handle". It can be empty.
+	ParentWindow string
+}
+
+// Show implements Backend.
+func (p *Portal) Show(opts Options) (*Result, error) {
+	options := portalOptions(opts)
+	method := "OpenFile"
+	switch opts.Mode {
+	case ModeSave:
+		method = "SaveFile"
+		if opts.InitialFileName != "" {
+			options["current_name"] = dbus.MakeVariant(opts.InitialFileName)
+		}
+	case ModeFolder:
+		options["directory"] = dbus.MakeVariant(true)
+	default:
+		options["multiple"] = dbus.MakeVariant(opts.Flags&AllowMultiSelect != 0)
+	}
+	return p.call(method, opts, options)
+}
+
+// SaveFiles asks the user for a folder to save the named files into and
+// returns the resulting paths in the order of names.
+func (p *Portal) SaveFiles(opts Options, names []string) (*Result, error) {
+	options := portalOptions(opts)
+	files := make([][]byte, len(names))
+	for i, name := range names {
+		files[i] = append([]byte(name), 0)
+	}
+	options["files"] = dbus.MakeVariant(files)
+	return p.call("SaveFiles", opts, options)
+}
+
+// portalOptions returns the options shared by every FileChooser method.
+func portalOptions(opts Options) map[string]dbus.Variant {
+	options := map[string]dbus.Variant{
+		"modal": dbus.MakeVariant(true),
+	}
+	if opts.InitialDir != "" {
+		options["current_folder"] = dbus.MakeVariant(append([]byte(opts.InitialDir), 0))
+	}
+	if opts.Mode != ModeFolder && len(opts.Filter) > 0 {
+		options["filters"] = dbus.MakeVariant(opts.Filter.PortalFilters())
+	}
+	return options
+}
+
+// call invokes method and waits for the Response signal of the returned
+// request object.
+func (p *Portal) call(method string, opts Options, options map[string]dbus.Variant) (*Result, error) {
+	conn := p.Conn
+	var err error
+	if conn == nil {
+		if conn, err = dbus.SessionBus(); err != nil {
+			return nil, err
+		}
+	}
+	names := conn.Names()
+	if len(names) == 0 {
+		return nil, fmt.Errorf("not connected to the bus")
+	}
+	// Subscribe to the request object before calling so the response cannot
+	// be missed. Its path is predictable from the sender and handle token.
+	token := fmt.Sprintf("winfileask%d", rand.Uint32())
+	sender := strings.ReplaceAll(strings.TrimPrefix(names[0], ":"), ".", "_")
+	path := dbus.ObjectPath(portalPath + "/request/" + sender + "/" + token)
+	options["handle_token"] = dbus.MakeVariant(token)
+	signals := make(chan *dbus.Signal, 1)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+	if err = p.watch(conn, path); err != nil {
+		return nil, err
+	}
+	defer p.unwatch(conn, path)
+
+	var handle dbus.ObjectPath
+	obj := conn.Object(portalDest, portalPath)
+	call := obj.Call(portalInterface+"."+method, 0, p.ParentWindow, opts.Title, options)
+	if err = call.Store(&handle); err != nil {
+		return nil, err
+	}
+	if handle != path {
+		// Portals older than version 0.9 ignore handle_token.
+		if err = p.watch(conn, handle); err != nil {
+			return nil, err
+		}
+		defer p.unwatch(conn, handle)
+	}
+	for sig := range signals {
+		if sig.Path != handle || sig.Name != requestSignal {
+			continue
+		}
+		return decodePortalResponse(opts, sig.Body)
+	}
+	return nil, fmt.Errorf("connection to the bus closed")
+}
+
+func (p *Portal) watch(conn *dbus.Conn, path dbus.ObjectPath) error {
+	return conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface("org.freedesktop.portal.Request"),
+		dbus.WithMatchMember("Response"),
+	)
+}
+
+func (p *Portal) unwatch(conn *dbus.Conn, path dbus.ObjectPath) {
+	conn.RemoveMatchSignal(
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface("org.freedesktop.portal.Request"),
+		dbus.WithMatchMember("Response"),
+	)
+}
+
+// decodePortalResponse converts the body of a Response signal, a response
+// code and a dictionary of results, into a Result.
+func decodePortalResponse(opts Options, body []interface{}) (*Result, error) {
+	if len(body) != 2 {
+		return nil, fmt.Errorf("malformed portal response")
+	}
+	code, ok := body[0].(uint32)
+	results, ok2 := body[1].(map[string]dbus.Variant)
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("malformed portal response")
+	}
+	switch code {
+	case 0:
+	case 1:
+		return nil, ErrCanceled
+	default:
+		return nil, fmt.Errorf("portal request failed")
+	}
+	var uris []string
+	if v, ok := results["uris"]; ok {
+		if err := v.Store(&uris); err != nil {
+			return nil, err
+		}
+	}
+	if len(uris) == 0 {
+		return nil, ErrCanceled
+	}
+	res := &Result{FilterIndex: -1}
+	for _, uri := range uris {
+		path, err := fileURIToPath(uri)
+		if err != nil {
+			return nil, err
+		}
+		res.Paths = append(res.Paths, path)
+	}
+	if v, ok := results["current_filter"]; ok {
+		var current PortalFilter
+		if v.Store(&current) == nil {
+			for i, f := range opts.Filter {
+				if f.Name == current.Name {
+					res.FilterIndex = i
+					break
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// fileURIToPath decodes a file:// URI into a local path.
+func fileURIToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" || (u.Host != "" && u.Host != "localhost") {
+		return "", fmt.Errorf("not a local file URI: %s", uri)
+	}
+	return u.Path, nil
+}
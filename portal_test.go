@@ -0,0 +1,334 @@
+package winfileask
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// busConfig configures a private bus that anyone may use.
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%DIR%</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// startBus runs a private dbus-daemon for the test and returns its address.
+func startBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not found")
+	}
+	dir := t.TempDir()
+	config := filepath.Join(dir, "bus.conf")
+	if err = os.WriteFile(config, []byte(strings.ReplaceAll(busConfig, "%DIR%", dir)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(daemon, "--config-file="+config, "--nofork", "--print-address=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	addr, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading the bus address: %v", err)
+	}
+	return strings.TrimSpace(addr)
+}
+
+// fakeChooser is a stand-in for the FileChooser interface of the portal. It
+// answers every call with code and results.
+type fakeChooser struct {
+	conn *dbus.Conn
+
+	mu      sync.Mutex
+	code    uint32
+	results map[string]dbus.Variant
+	last    portalCall
+	// filtersSig is the signature of the filters option as sent on the
+	// wire, which the exported methods do not see.
+	filtersSig string
+}
+
+// portalCall is a call received by a fakeChooser.
+type portalCall struct {
+	method  string
+	parent  string
+	title   string
+	options map[string]dbus.Variant
+	handle  dbus.ObjectPath
+}
+
+// answer sets the response to the following calls.
+func (f *fakeChooser) answer(code uint32, results map[string]dbus.Variant) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.code, f.results = code, results
+}
+
+// call returns the last call and the wire signature of its filters.
+func (f *fakeChooser) call() (portalCall, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.last, f.filtersSig
+}
+
+func (f *fakeChooser) OpenFile(sender dbus.Sender, parent, title string, options map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+	return f.respond("OpenFile", sender, parent, title, options)
+}
+
+func (f *fakeChooser) SaveFile(sender dbus.Sender, parent, title string, options map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+	return f.respond("SaveFile", sender, parent, title, options)
+}
+
+func (f *fakeChooser) SaveFiles(sender dbus.Sender, parent, title string, options map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+	return f.respond("SaveFiles", sender, parent, title, options)
+}
+
+// respond derives the request path from the sender and handle_token, as the
+// portal does, and emits the Response signal on it.
+func (f *fakeChooser) respond(method string, sender dbus.Sender, parent, title string, options map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var token string
+	if v, ok := options["handle_token"]; ok {
+		v.Store(&token)
+	}
+	name := strings.ReplaceAll(strings.TrimPrefix(string(sender), ":"), ".", "_")
+	handle := dbus.ObjectPath(portalPath + "/request/" + name + "/" + token)
+	f.last = portalCall{method, parent, title, options, handle}
+	if err := f.conn.Emit(handle, requestSignal, f.code, f.results); err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	return handle, nil
+}
+
+// startPortal starts a private bus with a fakeChooser on it and returns the
+// chooser and a Portal connected to the bus.
+func startPortal(t *testing.T) (*fakeChooser, *Portal) {
+	t.Helper()
+	addr := startBus(t)
+	chooser := &fakeChooser{results: map[string]dbus.Variant{}}
+	service, err := dbus.Connect(addr, dbus.WithIncomingInterceptor(func(msg *dbus.Message) {
+		if len(msg.Body) != 3 {
+			return
+		}
+		if options, ok := msg.Body[2].(map[string]dbus.Variant); ok {
+			chooser.mu.Lock()
+			chooser.filtersSig = options["filters"].Signature().String()
+			chooser.mu.Unlock()
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { service.Close() })
+	chooser.conn = service
+	if err = service.Export(chooser, portalPath, portalInterface); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := service.RequestName(portalDest, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("requesting %s: %v, %v", portalDest, reply, err)
+	}
+	client, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return chooser, &Portal{Conn: client, ParentWindow: "x11:1a"}
+}
+
+func uris(uris ...string) map[string]dbus.Variant {
+	return map[string]dbus.Variant{"uris": dbus.MakeVariant(uris)}
+}
+
+func TestPortalOpenFile(t *testing.T) {
+	chooser, p := startPortal(t)
+	results := uris("file:///home/u/a%20b.txt", "file://localhost/home/u/c.png")
+	results["current_filter"] = dbus.MakeVariant(PortalFilter{
+		Name:  "Images",
+		Rules: []PortalRule{{Kind: 0, Pattern: "*.png"}, {Kind: 0, Pattern: "*.jpg"}},
+	})
+	chooser.answer(0, results)
+	res, err := p.Show(Options{
+		Mode:       ModeOpen,
+		Title:      "Open files",
+		Filter:     testFilter,
+		InitialDir: "/home/u",
+		Flags:      AllowMultiSelect,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/home/u/a b.txt", "/home/u/c.png"}; !reflect.DeepEqual(res.Paths, want) || res.FilterIndex != 1 {
+		t.Errorf("result = %q, %d; want %q, 1", res.Paths, res.FilterIndex, want)
+	}
+	c, sig := chooser.call()
+	if c.method != "OpenFile" || c.parent != "x11:1a" || c.title != "Open files" {
+		t.Errorf("call = %s(%q, %q)", c.method, c.parent, c.title)
+	}
+	if sig != "a(sa(us))" {
+		t.Errorf("filters signature = %s, want a(sa(us))", sig)
+	}
+	var filters []PortalFilter
+	if err = c.options["filters"].Store(&filters); err != nil {
+		t.Fatal(err)
+	}
+	if want := testFilter.PortalFilters(); !reflect.DeepEqual(filters, want) {
+		t.Errorf("filters = %v, want %v", filters, want)
+	}
+	if v := c.options["multiple"].Value(); v != true {
+		t.Errorf("multiple = %v, want true", v)
+	}
+	if v := c.options["modal"].Value(); v != true {
+		t.Errorf("modal = %v, want true", v)
+	}
+	if v, _ := c.options["current_folder"].Value().([]byte); string(v) != "/home/u\x00" {
+		t.Errorf("current_folder = %q, want NUL terminated /home/u", v)
+	}
+	token, _ := c.options["handle_token"].Value().(string)
+	sender := strings.ReplaceAll(strings.TrimPrefix(p.Conn.Names()[0], ":"), ".", "_")
+	if want := dbus.ObjectPath(portalPath + "/request/" + sender + "/" + token); token == "" || c.handle != want {
+		t.Errorf("handle_token %q gave request path %s, want %s", token, c.handle, want)
+	}
+}
+
+func TestPortalSaveFile(t *testing.T) {
+	chooser, p := startPortal(t)
+	chooser.answer(0, uris("file:///tmp/report.pdf"))
+	res, err := p.Show(Options{Mode: ModeSave, InitialFileName: "report.pdf", Flags: SaveFlags})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/tmp/report.pdf"}; !reflect.DeepEqual(res.Paths, want) || res.FilterIndex != -1 {
+		t.Errorf("result = %q, %d; want %q, -1", res.Paths, res.FilterIndex, want)
+	}
+	c, _ := chooser.call()
+	if c.method != "SaveFile" {
+		t.Errorf("method = %s, want SaveFile", c.method)
+	}
+	if v := c.options["current_name"].Value(); v != "report.pdf" {
+		t.Errorf("current_name = %v, want report.pdf", v)
+	}
+	for _, name := range []string{"multiple", "filters", "current_folder", "directory"} {
+		if _, ok := c.options[name]; ok {
+			t.Errorf("unexpected option %s", name)
+		}
+	}
+}
+
+func TestPortalFolder(t *testing.T) {
+	chooser, p := startPortal(t)
+	chooser.answer(0, uris("file:///srv/data"))
+	res, err := p.Show(Options{Mode: ModeFolder, Filter: testFilter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/srv/data"}; !reflect.DeepEqual(res.Paths, want) {
+		t.Errorf("paths = %q, want %q", res.Paths, want)
+	}
+	c, _ := chooser.call()
+	if c.method != "OpenFile" || c.options["directory"].Value() != true {
+		t.Errorf("%s with directory %v, want OpenFile with directory true", c.method, c.options["directory"])
+	}
+	if _, ok := c.options["filters"]; ok {
+		t.Error("folder dialog was given filters")
+	}
+}
+
+func TestPortalSaveFiles(t *testing.T) {
+	chooser, p := startPortal(t)
+	chooser.answer(0, uris("file:///out/a.txt", "file:///out/b.txt"))
+	res, err := p.SaveFiles(Options{Title: "Export"}, []string{"a.txt", "b.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/out/a.txt", "/out/b.txt"}; !reflect.DeepEqual(res.Paths, want) {
+		t.Errorf("paths = %q, want %q", res.Paths, want)
+	}
+	c, _ := chooser.call()
+	if c.method != "SaveFiles" || c.title != "Export" {
+		t.Errorf("call = %s(%q)", c.method, c.title)
+	}
+	var files [][]byte
+	if err = c.options["files"].Store(&files); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]byte{[]byte("a.txt\x00"), []byte("b.txt\x00")}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files = %q, want %q", files, want)
+	}
+}
+
+func TestPortalResponseCodes(t *testing.T) {
+	chooser, p := startPortal(t)
+	chooser.answer(1, uris("file:///tmp/a.txt"))
+	if _, err := p.Show(Options{}); err != ErrCanceled {
+		t.Errorf("code 1: err = %v, want ErrCanceled", err)
+	}
+	chooser.answer(2, uris("file:///tmp/a.txt"))
+	if _, err := p.Show(Options{}); err == nil || err == ErrCanceled {
+		t.Errorf("code 2: err = %v, want a failure", err)
+	}
+}
+
+func TestDecodePortalResponse(t *testing.T) {
+	opts := Options{Filter: testFilter}
+	current := dbus.MakeVariant(PortalFilter{Name: "Text"})
+	tests := []struct {
+		name  string
+		body  []interface{}
+		paths []string
+		index int
+		err   error
+	}{
+		{"success", []interface{}{uint32(0), uris("file:///a/%C3%A9t%C3%A9.txt")}, []string{"/a/été.txt"}, -1, nil},
+		{"current filter", []interface{}{uint32(0), map[string]dbus.Variant{
+			"uris":           dbus.MakeVariant([]string{"file:///a.txt"}),
+			"current_filter": current,
+		}}, []string{"/a.txt"}, 0, nil},
+		{"no uris", []interface{}{uint32(0), map[string]dbus.Variant{}}, nil, 0, ErrCanceled},
+		{"canceled", []interface{}{uint32(1), uris("file:///a.txt")}, nil, 0, ErrCanceled},
+		{"other", []interface{}{uint32(2), uris("file:///a.txt")}, nil, 0, errors.New("failure")},
+		{"malformed", []interface{}{uint32(0)}, nil, 0, errors.New("failure")},
+		{"wrong types", []interface{}{"0", uris()}, nil, 0, errors.New("failure")},
+		{"remote host", []interface{}{uint32(0), uris("file://server/a.txt")}, nil, 0, errors.New("failure")},
+		{"not a file", []interface{}{uint32(0), uris("https://example.com/a.txt")}, nil, 0, errors.New("failure")},
+	}
+	for _, tt := range tests {
+		res, err := decodePortalResponse(opts, tt.body)
+		switch {
+		case tt.err == ErrCanceled && err != ErrCanceled:
+			t.Errorf("%s: err = %v, want ErrCanceled", tt.name, err)
+		case tt.err != nil && (err == nil || (tt.err != ErrCanceled && err == ErrCanceled)):
+			t.Errorf("%s: err = %v, want a failure", tt.name, err)
+		case tt.err == nil && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.err == nil && (!reflect.DeepEqual(res.Paths, tt.paths) || res.FilterIndex != tt.index):
+			t.Errorf("%s: result = %q, %d; want %q, %d", tt.name, res.Paths, res.FilterIndex, tt.paths, tt.index)
+		}
+	}
+}
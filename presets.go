@@ -0,0 +1,99 @@
+package winfileask
+
+import "strings"
+
+// Common filters for use with FilterBuilder.
+var (
+	ImageFiles = Filter{
+		Name:    "Images",
+		Pattern: "*.png;*.jpg;*.jpeg;*.gif;*.bmp;*.webp;*.tif;*.tiff;*.svg;*.ico;*.heic;*.avif",
+	}
+	AudioFiles = Filter{
+		Name:    "Audio",
+		Pattern: "*.mp3;*.wav;*.flac;*.ogg;*.oga;*.opus;*.m4a;*.aac;*.wma;*.aiff",
+	}
+	VideoFiles = Filter{
+		Name:    "Video",
+		Pattern: "*.mp4;*.m4v;*.mkv;*.webm;*.avi;*.mov;*.wmv;*.mpg;*.mpeg;*.ogv",
+	}
+	DocumentFiles = Filter{
+		Name:    "Documents",
+		Pattern: "*.pdf;*.doc;*.docx;*.odt;*.rtf;*.txt;*.md;*.xls;*.xlsx;*.ods;*.csv;*.ppt;*.pptx;*.odp",
+	}
+	ArchiveFiles = Filter{
+		Name:    "Archives",
+		Pattern: "*.zip;*.7z;*.rar;*.tar;*.gz;*.tgz;*.bz2;*.xz;*.zst;*.tar.gz;*.tar.bz2;*.tar.xz",
+	}
+	SourceFiles = Filter{
+		Name:    "Source code",
+		Pattern: "*.go;*.c;*.h;*.cpp;*.hpp;*.cc;*.cs;*.java;*.kt;*.py;*.rb;*.rs;*.js;*.ts;*.php;*.swift;*.sh;*.ps1",
+	}
+	AllFiles = Filter{
+		Name:    "All files",
+		Pattern: "*.*",
+	}
+)
+
+// FilterBuilder builds a FileFilter from a list of filters.
+type FilterBuilder struct {
+	// AllSupported adds a first entry, named "All supported files", matching
+	// the patterns of every filter, if there is more than one.
+	AllSupported bool
+	// AllFiles adds AllFiles as the last entry unless a filter already
+	// matches every file.
+	AllFiles bool
+	// ShowPatterns appends the patterns to the names of the filters, as in
+	// "Images (*.png;*.jpg)", unless a name already ends with ')'.
+	ShowPatterns bool
+}
+
+// Build returns the filters, with duplicate patterns removed, as configured
+// by b.
+func (b FilterBuilder) Build(filters ...Filter) FileFilter {
+	var ff FileFilter
+	var all []string
+	hasAll := false
+	for _, f := range filters {
+		globs := dedupeGlobs(nil, f.Globs())
+		for _, g := range globs {
+			if g == "*" || g == "*.*" {
+				hasAll = true
+			} else {
+				all = dedupeGlobs(all, []string{g})
+			}
+		}
+		f.Pattern = strings.Join(globs, ";")
+		ff = append(ff, f)
+	}
+	if b.AllSupported && len(filters) > 1 && len(all) > 0 {
+		ff = append(FileFilter{{Name: "All supported files", Pattern: strings.Join(all, ";")}}, ff...)
+	}
+	if b.AllFiles && !hasAll {
+		ff = append(ff, AllFiles)
+	}
+	if b.ShowPatterns {
+		for i, f := range ff {
+			if !strings.HasSuffix(f.Name, ")") {
+				ff[i].Name = f.Name + " (" + f.Pattern + ")"
+			}
+		}
+	}
+	return ff
+}
+
+// dedupeGlobs appends the globs not already in dst, ignoring case.
+func dedupeGlobs(dst, globs []string) []string {
+	for _, g := range globs {
+		dup := false
+		for _, d := range dst {
+			if strings.EqualFold(d, g) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			dst = append(dst, g)
+		}
+	}
+	return dst
+}
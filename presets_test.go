@@ -0,0 +1,99 @@
+package winfileask
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	presets := FileFilter{ImageFiles, AudioFiles, VideoFiles, DocumentFiles, ArchiveFiles, SourceFiles, AllFiles}
+	if err := presets.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range presets {
+		if globs := f.Globs(); len(dedupeGlobs(nil, globs)) != len(globs) {
+			t.Errorf("%s has duplicate patterns: %s", f.Name, f.Pattern)
+		}
+	}
+}
+
+func TestFilterBuilder(t *testing.T) {
+	text := Filter{Name: "Text", Pattern: "*.txt;*.TXT; *.log"}
+	images := Filter{Name: "Images", Pattern: "*.png;*.txt"}
+	everything := Filter{Name: "Everything", Pattern: "*"}
+	tests := []struct {
+		name    string
+		b       FilterBuilder
+		filters []Filter
+		want    FileFilter
+	}{
+		{"nothing", FilterBuilder{}, nil, nil},
+		{"dedupe", FilterBuilder{}, []Filter{text, images}, FileFilter{
+			{Name: "Text", Pattern: "*.txt;*.log"},
+			{Name: "Images", Pattern: "*.png;*.txt"},
+		}},
+		{"all supported", FilterBuilder{AllSupported: true}, []Filter{text, images}, FileFilter{
+			{Name: "All supported files", Pattern: "*.txt;*.log;*.png"},
+			{Name: "Text", Pattern: "*.txt;*.log"},
+			{Name: "Images", Pattern: "*.png;*.txt"},
+		}},
+		{"all supported of one filter", FilterBuilder{AllSupported: true}, []Filter{text}, FileFilter{
+			{Name: "Text", Pattern: "*.txt;*.log"},
+		}},
+		{"all supported skips catch-alls", FilterBuilder{AllSupported: true}, []Filter{everything, AllFiles}, FileFilter{
+			everything, AllFiles,
+		}},
+		{"all supported without catch-alls", FilterBuilder{AllSupported: true}, []Filter{text, everything}, FileFilter{
+			{Name: "All supported files", Pattern: "*.txt;*.log"},
+			{Name: "Text", Pattern: "*.txt;*.log"},
+			everything,
+		}},
+		{"all files", FilterBuilder{AllFiles: true}, []Filter{text}, FileFilter{
+			{Name: "Text", Pattern: "*.txt;*.log"},
+			AllFiles,
+		}},
+		{"all files already there", FilterBuilder{AllFiles: true}, []Filter{text, everything}, FileFilter{
+			{Name: "Text", Pattern: "*.txt;*.log"},
+			everything,
+		}},
+		{"all files alone", FilterBuilder{AllFiles: true}, nil, FileFilter{AllFiles}},
+		{"show patterns", FilterBuilder{AllSupported: true, AllFiles: true, ShowPatterns: true}, []Filter{text, images, {Name: "Data (v2)", Pattern: "*.dat"}}, FileFilter{
+			{Name: "All supported files (*.txt;*.log;*.png;*.dat)", Pattern: "*.txt;*.log;*.png;*.dat"},
+			{Name: "Text (*.txt;*.log)", Pattern: "*.txt;*.log"},
+			{Name: "Images (*.png;*.txt)", Pattern: "*.png;*.txt"},
+			{Name: "Data (v2)", Pattern: "*.dat"},
+			{Name: "All files (*.*)", Pattern: "*.*"},
+		}},
+	}
+	for _, tt := range tests {
+		got := tt.b.Build(tt.filters...)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Build() =\n%v\nwant\n%v", tt.name, got, tt.want)
+		}
+		if err := got.Validate(); err != nil {
+			t.Errorf("%s: built filter is invalid: %v", tt.name, err)
+		}
+	}
+	if text.Pattern != "*.txt;*.TXT; *.log" {
+		t.Errorf("Build modified its argument: %q", text.Pattern)
+	}
+}
+
+func TestFilterBuilderPresets(t *testing.T) {
+	ff := FilterBuilder{AllSupported: true, AllFiles: true}.Build(ImageFiles, DocumentFiles)
+	if len(ff) != 4 || ff[0].Name != "All supported files" || !reflect.DeepEqual(ff[3], AllFiles) {
+		t.Fatalf("Build() = %v", ff)
+	}
+	for _, name := range []string{"a.PNG", "b.pdf", "c.csv"} {
+		if ff.MatchIndex(name) != 0 {
+			t.Errorf("%s does not match the first entry", name)
+		}
+	}
+	if i := ff.MatchIndex("d.exe"); i != 3 {
+		t.Errorf("MatchIndex(d.exe) = %d, want the All files entry", i)
+	}
+	if n := strings.Count(ff[0].Pattern, ";") + 1; n != len(ImageFiles.Globs())+len(DocumentFiles.Globs()) {
+		t.Errorf("All supported files has %d patterns", n)
+	}
+}
@@ -0,0 +1,141 @@
+package winfileask
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Prompt is a Backend that asks for paths with plain line-based prompts. It
+// needs no terminal capabilities, so it suits scripts and CI jobs that only
+// have a standard input.
+//
+// A single path is read per line; with AllowMultiSelect, paths are read one
+// per line until an empty line or the end of input. Relative paths are
+// resolved against InitialDir. FileMustExist, PathMustExist and
+// OverwritePrompt are enforced as the native dialog would, printing the
+// problem and asking again.
+type Prompt struct {
+	// In is read for answers. If it is nil, os.Stdin is used.
+	In io.Reader
+	// Out receives the prompts. If it is nil, os.Stderr is used so that
+	// prompts do not mix with the program's output.
+	Out io.Writer
+}
+
+// Show implements Backend.
+func (p *Prompt) Show(opts Options) (*Result, error) {
+	in, out := p.In, p.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+	r := bufio.NewReader(in)
+	fmt.Fprintln(out, opts.title())
+	if opts.Mode != ModeFolder {
+		for _, f := range opts.Filter {
+			fmt.Fprintf(out, "  %s (%s)\n", f.Name, f.Pattern)
+		}
+	}
+	multi := opts.Mode == ModeOpen && opts.Flags&AllowMultiSelect != 0
+	for {
+		if multi {
+			fmt.Fprint(out, "Paths, one per line, followed by an empty line: ")
+		} else {
+			fmt.Fprint(out, "Path: ")
+		}
+		var paths []string
+		for {
+			line, err := readLine(r)
+			if err == io.EOF && line == "" && len(paths) == 0 {
+				return nil, ErrCanceled
+			} else if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if line != "" {
+				paths = append(paths, resolvePath(opts.startDir(), line))
+			}
+			if !multi || line == "" || err == io.EOF {
+				break
+			}
+		}
+		if len(paths) == 0 {
+			return nil, ErrCanceled
+		}
+		ok, err := checkPaths(r, out, opts, paths)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &Result{Paths: paths, FilterIndex: -1}, nil
+		}
+	}
+}
+
+// readLine reads a line without its line ending.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+func resolvePath(dir, path string) string {
+	if dir == "" || filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
+
+// checkPaths enforces the existence flags of opts on paths and asks before
+// overwriting. It reports whether the paths were accepted.
+func checkPaths(r *bufio.Reader, out io.Writer, opts Options, paths []string) (bool, error) {
+	for _, path := range paths {
+		if !opts.allowed(path) {
+			fmt.Fprintf(out, "%s\nYou can only choose from the allowed folders.\n", path)
+			return false, nil
+		}
+		if opts.Mode != ModeFolder {
+			if err := opts.Extensions.Check(path); err != nil {
+				fmt.Fprintln(out, err)
+				return false, nil
+			}
+		}
+		fi, err := os.Stat(path)
+		exists := err == nil
+		switch {
+		case opts.Mode == ModeFolder && (!exists || !fi.IsDir()):
+			fmt.Fprintf(out, "%s is not a folder.\n", path)
+			return false, nil
+		case opts.Mode != ModeFolder && exists && fi.IsDir():
+			fmt.Fprintf(out, "%s is a folder.\n", path)
+			return false, nil
+		case opts.Mode == ModeOpen && opts.Flags&FileMustExist != 0 && !exists:
+			fmt.Fprintf(out, "%s\nFile not found.\nCheck the file name and try again.\n", path)
+			return false, nil
+		}
+		if !exists && opts.Flags&(PathMustExist|FileMustExist) != 0 {
+			if fi, err := os.Stat(filepath.Dir(path)); err != nil || !fi.IsDir() {
+				fmt.Fprintf(out, "%s\nPath does not exist.\nCheck the path and try again.\n", path)
+				return false, nil
+			}
+		}
+		if opts.Mode == ModeSave && exists && opts.Flags&OverwritePrompt != 0 {
+			fmt.Fprintf(out, "%s already exists.\nDo you want to replace it? [y/N] ", path)
+			answer, err := readLine(r)
+			if err != nil && err != io.EOF {
+				return false, err
+			}
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				if err == io.EOF {
+					return false, ErrCanceled
+				}
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
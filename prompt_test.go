@@ -0,0 +1,83 @@
+package winfileask
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrompt(t *testing.T) {
+	dir := makeTree(t, "a.txt", "b.txt", "sub/")
+	tests := []struct {
+		name  string
+		opts  Options
+		input string
+		paths []string
+		err   error
+		// out is printed when the first answer is refused.
+		out string
+	}{
+		{"open", Options{Flags: OpenFlags}, "a.txt\n", []string{"a.txt"}, nil, ""},
+		{"crlf", Options{Flags: OpenFlags}, "a.txt\r\n", []string{"a.txt"}, nil, ""},
+		{"absolute", Options{Flags: OpenFlags}, filepath.Join(dir, "b.txt") + "\n", []string{"b.txt"}, nil, ""},
+		{"no final newline", Options{Flags: OpenFlags}, "a.txt", []string{"a.txt"}, nil, ""},
+		{"file must exist", Options{Flags: OpenFlags}, "x.txt\nb.txt\n", []string{"b.txt"}, nil, "File not found."},
+		{"missing file allowed", Options{}, "x.txt\n", []string{"x.txt"}, nil, ""},
+		{"path must exist", Options{Flags: PathMustExist}, "no/x.txt\nsub/x.txt\n", []string{"sub/x.txt"}, nil, "Path does not exist."},
+		{"folder for a file", Options{Flags: OpenFlags}, "sub\na.txt\n", []string{"a.txt"}, nil, "is a folder."},
+		{"multiple", Options{Flags: OpenFlags | AllowMultiSelect}, "a.txt\nb.txt\n\n", []string{"a.txt", "b.txt"}, nil, ""},
+		{"multiple at end of input", Options{Flags: OpenFlags | AllowMultiSelect}, "a.txt\nb.txt", []string{"a.txt", "b.txt"}, nil, ""},
+		{"multiple with a missing file", Options{Flags: OpenFlags | AllowMultiSelect}, "a.txt\nx.txt\n\nb.txt\n\n", []string{"b.txt"}, nil, "File not found."},
+		{"empty line", Options{}, "\n", nil, ErrCanceled, ""},
+		{"end of input", Options{}, "", nil, ErrCanceled, ""},
+		{"save", Options{Mode: ModeSave, Flags: SaveFlags}, "new.txt\n", []string{"new.txt"}, nil, ""},
+		{"overwrite yes", Options{Mode: ModeSave, Flags: SaveFlags}, "a.txt\ny\n", []string{"a.txt"}, nil, ""},
+		{"overwrite YES", Options{Mode: ModeSave, Flags: SaveFlags}, "a.txt\n YES \n", []string{"a.txt"}, nil, ""},
+		{"overwrite no", Options{Mode: ModeSave, Flags: SaveFlags}, "a.txt\nn\nnew.txt\n", []string{"new.txt"}, nil, "already exists."},
+		{"overwrite default", Options{Mode: ModeSave, Flags: SaveFlags}, "a.txt\n\nnew.txt\n", []string{"new.txt"}, nil, "already exists."},
+		{"overwrite at end of input", Options{Mode: ModeSave, Flags: SaveFlags}, "a.txt\n", nil, ErrCanceled, "already exists."},
+		{"overwrite without prompt", Options{Mode: ModeSave}, "a.txt\n", []string{"a.txt"}, nil, ""},
+		{"save to a folder", Options{Mode: ModeSave, Flags: SaveFlags}, "sub\nc.txt\n", []string{"c.txt"}, nil, "is a folder."},
+		{"folder", Options{Mode: ModeFolder}, "sub\n", []string{"sub"}, nil, ""},
+		{"folder for a file", Options{Mode: ModeFolder}, "a.txt\nsub\n", []string{"sub"}, nil, "is not a folder."},
+		{"missing folder", Options{Mode: ModeFolder}, "nothing\n", nil, ErrCanceled, "is not a folder."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.InitialDir = dir
+			var out bytes.Buffer
+			p := &Prompt{In: strings.NewReader(tt.input), Out: &out}
+			res, err := p.Show(tt.opts)
+			if err != tt.err {
+				t.Fatalf("err = %v, want %v\n%s", err, tt.err, out.String())
+			}
+			if err == nil {
+				var want []string
+				for _, path := range tt.paths {
+					want = append(want, filepath.Join(dir, filepath.FromSlash(path)))
+				}
+				if !reflect.DeepEqual(res.Paths, want) || res.FilterIndex != -1 {
+					t.Errorf("result = %q, %d; want %q, -1", res.Paths, res.FilterIndex, want)
+				}
+			}
+			if tt.out != "" && !strings.Contains(out.String(), tt.out) {
+				t.Errorf("output %q does not contain %q", out.String(), tt.out)
+			}
+		})
+	}
+}
+
+func TestPromptOutput(t *testing.T) {
+	var out bytes.Buffer
+	p := &Prompt{In: strings.NewReader(""), Out: &out}
+	opts := Options{Title: "Open data", Filter: testFilter, Flags: AllowMultiSelect}
+	if _, err := p.Show(opts); err != ErrCanceled {
+		t.Fatalf("err = %v, want ErrCanceled", err)
+	}
+	want := "Open data\n  Text (*.txt)\n  Images (*.png;*.jpg)\nPaths, one per line, followed by an empty line: "
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,97 @@
+package winfileask
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// RootError reports a selected path outside Options.AllowedRoots.
+type RootError struct {
+	Path string
+}
+
+func (e *RootError) Error() string {
+	return fmt.Sprintf("%s: path is outside the allowed folders", e.Path)
+}
+
+// allowed reports whether path is inside one of the AllowedRoots of o, or
+// whether o has none.
+func (o Options) allowed(path string) bool {
+	if len(o.AllowedRoots) == 0 {
+		return true
+	}
+	path = canonicalPath(path)
+	for _, root := range o.AllowedRoots {
+		if within(canonicalPath(root), path) {
+			return true
+		}
+	}
+	return false
+}
+
+// startDir returns the directory a dialog for o starts in: InitialDir, or
+// the current directory if InitialDir is empty. With AllowedRoots, it is the
+// first root instead if that directory is outside them.
+func (o Options) startDir() string {
+	if len(o.AllowedRoots) == 0 {
+		return o.InitialDir
+	}
+	dir := o.InitialDir
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return o.AllowedRoots[0]
+		}
+	}
+	if !o.allowed(dir) {
+		return o.AllowedRoots[0]
+	}
+	return dir
+}
+
+// rootMessage returns the message a dialog shows to refuse paths because one
+// is outside AllowedRoots, or "" if they are all allowed.
+func (o Options) rootMessage(paths []string) string {
+	for _, path := range paths {
+		if !o.allowed(path) {
+			return fmt.Sprintf("%s\nis outside the folders you can choose from:\n%s", path, strings.Join(o.AllowedRoots, "\n"))
+		}
+	}
+	return ""
+}
+
+// canonicalPath returns path absolute and cleaned, with the links and 8.3
+// short names in the part that exists resolved, so that paths naming the same
+// file compare equal.
+func canonicalPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	dir, rest := existingPrefix(abs)
+	if dir == "" {
+		return abs
+	}
+	if target, err := finalPath(dir); err == nil {
+		dir = target
+	}
+	return filepath.Join(longPathName(dir), rest)
+}
+
+// within reports whether path is root or inside it. Both must be canonical.
+// Case is ignored where file systems usually do.
+func within(root, path string) bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		root, path = strings.ToLower(root), strings.ToLower(path)
+	}
+	if path == root {
+		return true
+	}
+	if !strings.HasSuffix(root, string(filepath.Separator)) {
+		root += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, root)
+}
@@ -0,0 +1,161 @@
+package winfileask
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAllowed(t *testing.T) {
+	dir := linkTree(t, map[string]string{
+		"root/out": "../outside",
+		"in":       "root/sub",
+	}, "root/sub/a.txt", "root2/", "outside/b.txt", "rootx/")
+	root := filepath.Join(dir, "root")
+	opts := Options{AllowedRoots: []string{root, filepath.Join(dir, "root2") + string(filepath.Separator)}}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"root", true},
+		{"root/sub/a.txt", true},
+		{"root/sub/new.txt", true},
+		{"root/new/dir/new.txt", true},
+		{"root/sub/../sub/a.txt", true},
+		{"root2/c.txt", true},
+		{"root/../outside/b.txt", false},
+		{"rootx/c.txt", false},
+		{"outside/b.txt", false},
+		{".", false},
+		// Links are followed, whichever way they cross the roots.
+		{"root/out/b.txt", false},
+		{"in/a.txt", true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, filepath.FromSlash(tt.path))
+		if got := opts.allowed(path); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if !(Options{}).allowed(dir) {
+		t.Error("allowed without roots = false, want true")
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		if !opts.allowed(strings.ToUpper(filepath.Join(root, "sub", "a.txt"))) {
+			t.Error("allowed is case sensitive")
+		}
+	}
+}
+
+func TestStartDir(t *testing.T) {
+	dir := linkTree(t, nil, "root/sub/", "outside/")
+	root := filepath.Join(dir, "root")
+	sub := filepath.Join(root, "sub")
+	outside := filepath.Join(dir, "outside")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	tests := []struct {
+		name  string
+		opts  Options
+		chdir string
+		want  string
+	}{
+		{"no roots", Options{InitialDir: outside}, "", outside},
+		{"no roots or initial dir", Options{}, "", ""},
+		{"inside", Options{InitialDir: sub, AllowedRoots: []string{root}}, "", sub},
+		{"outside", Options{InitialDir: outside, AllowedRoots: []string{root}}, "", root},
+		{"current dir inside", Options{AllowedRoots: []string{root}}, sub, sub},
+		{"current dir outside", Options{AllowedRoots: []string{root}}, outside, root},
+		{"relative", Options{InitialDir: "sub", AllowedRoots: []string{root}}, root, "sub"},
+	}
+	for _, tt := range tests {
+		if tt.chdir != "" {
+			if err = os.Chdir(tt.chdir); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := tt.opts.startDir(); got != tt.want {
+			t.Errorf("%s: startDir() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRootsDialoger(t *testing.T) {
+	dir := linkTree(t, nil, "root/a.txt", "outside/b.txt")
+	root := filepath.Join(dir, "root")
+	var shown Options
+	answer := filepath.Join(dir, "outside", "b.txt")
+	d := NewDialoger(backendFunc(func(opts Options) (*Result, error) {
+		shown = opts
+		return &Result{Paths: []string{answer}, FilterIndex: -1}, nil
+	}))
+	opts := Options{InitialDir: filepath.Join(dir, "outside"), AllowedRoots: []string{root}}
+	_, err := d.Open(opts)
+	if re, ok := err.(*RootError); !ok || re.Path != answer {
+		t.Errorf("err = %v, want a *RootError for %q", err, answer)
+	}
+	if shown.InitialDir != root {
+		t.Errorf("dialog started in %q, want %q", shown.InitialDir, root)
+	}
+	answer = filepath.Join(root, "a.txt")
+	if res, err := d.Open(opts); err != nil || res.Path() != answer {
+		t.Errorf("inside the root: %v, %v", res, err)
+	}
+	// A link resolved out of the root is still caught.
+	if err = os.Symlink(filepath.Join(dir, "outside", "b.txt"), filepath.Join(root, "b.txt")); err != nil {
+		t.Skip(err)
+	}
+	answer = filepath.Join(root, "b.txt")
+	opts.Links = LinkResolve
+	if _, err = d.Open(opts); err == nil {
+		t.Error("link out of the root: err = nil, want a *RootError")
+	}
+}
+
+func TestShowFileDialogRoots(t *testing.T) {
+	dir := linkTree(t, nil, "root/a.txt", "outside/b.txt")
+	root := filepath.Join(dir, "root")
+	opts := Options{Filter: testFilter, Flags: OpenFlags, AllowedRoots: []string{root}}
+
+	dlg := &fakeComdlg{file: filepath.Join(root, "a.txt")}
+	res, err := showFileDialog(dlg, nil, opts)
+	if err != nil || res.Path() != dlg.file || dlg.refused != "" {
+		t.Errorf("inside the root: %v, %v, refused %q", res, err, dlg.refused)
+	}
+	if want := OpenFlags | EnableHook | Explorer; dlg.flags != want {
+		t.Errorf("flags = %#x, want %#x", dlg.flags, want)
+	}
+
+	outside := filepath.Join(dir, "outside", "b.txt")
+	dlg = &fakeComdlg{file: outside}
+	if _, err = showFileDialog(dlg, nil, opts); err != ErrCanceled {
+		t.Errorf("outside the root: err = %v, want ErrCanceled", err)
+	}
+	if !strings.Contains(dlg.refused, outside) || !strings.Contains(dlg.refused, root) {
+		t.Errorf("refused with %q, want a message naming %q and %q", dlg.refused, outside, root)
+	}
+
+	// Old-style dialog boxes get no CDN_FILEOK, so the roots make them
+	// Explorer-style.
+	opts.Flags |= AllowMultiSelect | NoLongNames
+	dlg = &fakeComdlg{file: filepath.Join(root, "a.txt")}
+	if res, err = showFileDialog(dlg, nil, opts); err != nil || res.Path() != dlg.file {
+		t.Errorf("old-style in the root: %v, %v", res, err)
+	}
+	if dlg.flags&(Explorer|EnableHook) != Explorer|EnableHook {
+		t.Errorf("flags = %#x, want Explorer and EnableHook", dlg.flags)
+	}
+
+	dlg = &fakeComdlg{file: outside}
+	if _, err = showFileDialog(dlg, nil, Options{Flags: OpenFlags}); err != nil || dlg.flags&EnableHook != 0 || dlg.refused != "" {
+		t.Errorf("without roots: err %v, flags %#x, refused %q", err, dlg.flags, dlg.refused)
+	}
+	if n := len(hookData.m); n != 0 {
+		t.Errorf("%d hook values left behind", n)
+	}
+}
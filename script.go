@@ -0,0 +1,134 @@
+package winfileask
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// The environment variables that make Detect replay or record dialog
+// answers. Their value is the name of a script file.
+const (
+	ScriptEnv = "WINFILEASK_SCRIPT"
+	RecordEnv = "WINFILEASK_RECORD"
+)
+
+// Answer is a scripted answer to a dialog. A script file is a JSON array of
+// answers.
+type Answer struct {
+	// DialogID and Title, if set, restrict the answer to dialogs with the
+	// same Options.DialogID and Options.Title.
+	DialogID string `json:"dialogId,omitempty"`
+	Title    string `json:"title,omitempty"`
+	// Paths is the selection. It is ignored if Canceled is set.
+	Paths []string `json:"paths,omitempty"`
+	// FilterIndex is the Result.FilterIndex of the answer. It is -1,
+	// unknown, if a script leaves it out.
+	FilterIndex int `json:"filterIndex"`
+	// Canceled answers the dialog as if the user dismissed it.
+	Canceled bool `json:"canceled,omitempty"`
+}
+
+// UnmarshalJSON decodes an answer of a script file, taking a missing
+// filterIndex as -1 rather than as the first filter.
+func (a *Answer) UnmarshalJSON(data []byte) error {
+	type answer Answer
+	v := answer{FilterIndex: -1}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*a = Answer(v)
+	return nil
+}
+
+func (a Answer) matches(opts Options) bool {
+	return (a.DialogID == "" || a.DialogID == opts.DialogID) &&
+		(a.Title == "" || a.Title == opts.Title)
+}
+
+// Replay is a Backend that answers dialogs from a script instead of showing
+// them, for end-to-end tests. Each answer is used once. A dialog gets the
+// first unused answer that matches it, so answers without a DialogID or
+// Title are used in order.
+type Replay struct {
+	mu      sync.Mutex
+	answers []Answer
+	used    []bool
+}
+
+// NewReplay returns a Replay that gives the answers.
+func NewReplay(answers []Answer) *Replay {
+	return &Replay{answers: answers, used: make([]bool, len(answers))}
+}
+
+// LoadReplay returns a Replay that gives the answers in the script file
+// name.
+func LoadReplay(name string) (*Replay, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var answers []Answer
+	if err = json.Unmarshal(data, &answers); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return NewReplay(answers), nil
+}
+
+// Show implements Backend.
+func (r *Replay) Show(opts Options) (*Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, a := range r.answers {
+		if r.used[i] || !a.matches(opts) {
+			continue
+		}
+		r.used[i] = true
+		if a.Canceled || len(a.Paths) == 0 {
+			return nil, ErrCanceled
+		}
+		return &Result{
+			Paths:       append([]string(nil), a.Paths...),
+			FilterIndex: a.FilterIndex,
+		}, nil
+	}
+	return nil, fmt.Errorf("no scripted answer for dialog %q (id %q)", opts.Title, opts.DialogID)
+}
+
+// Recorder is a Backend that shows dialogs with another Backend and writes
+// the answers to a script file that Replay can play back. The file is
+// rewritten after every dialog, so it is complete even if the program does
+// not exit cleanly.
+type Recorder struct {
+	Backend Backend
+	// Path is the name of the script file.
+	Path string
+
+	mu      sync.Mutex
+	answers []Answer
+}
+
+// Show implements Backend.
+func (r *Recorder) Show(opts Options) (*Result, error) {
+	res, err := r.Backend.Show(opts)
+	if err != nil && err != ErrCanceled {
+		return res, err
+	}
+	a := Answer{DialogID: opts.DialogID, Title: opts.Title, Canceled: err == ErrCanceled, FilterIndex: -1}
+	if res != nil {
+		a.Paths = res.Paths
+		a.FilterIndex = res.FilterIndex
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.answers = append(r.answers, a)
+	data, jerr := json.MarshalIndent(r.answers, "", "\t")
+	if jerr != nil {
+		return nil, jerr
+	}
+	if werr := os.WriteFile(r.Path, append(data, '\n'), 0o666); werr != nil {
+		return nil, werr
+	}
+	return res, err
+}
@@ -0,0 +1,124 @@
+package winfileask
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dialogs := []struct {
+		opts Options
+		res  *Result
+		err  error
+	}{
+		{Options{DialogID: "import", Title: "Import"}, &Result{Paths: []string{"/data/a.csv", "/data/b.csv"}, FilterIndex: 1}, nil},
+		{Options{Title: "Export"}, nil, ErrCanceled},
+		{Options{DialogID: "broken"}, nil, errors.New("backend failed")},
+		{Options{Mode: ModeFolder}, &Result{Paths: []string{"/out"}, FilterIndex: -1}, nil},
+	}
+	var n int
+	script := filepath.Join(t.TempDir(), "script.json")
+	r := &Recorder{Path: script, Backend: backendFunc(func(Options) (*Result, error) {
+		d := dialogs[n]
+		n++
+		return d.res, d.err
+	})}
+	for _, d := range dialogs {
+		res, err := r.Show(d.opts)
+		if err != d.err || !reflect.DeepEqual(res, d.res) {
+			t.Errorf("recording %+v: got %+v, %v; want %+v, %v", d.opts, res, err, d.res, d.err)
+		}
+	}
+
+	replay, err := LoadReplay(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range dialogs {
+		if d.err != nil && d.err != ErrCanceled {
+			// Failures are not recorded.
+			continue
+		}
+		res, err := replay.Show(d.opts)
+		if err != d.err || !reflect.DeepEqual(res, d.res) {
+			t.Errorf("replaying %+v: got %+v, %v; want %+v, %v", d.opts, res, err, d.res, d.err)
+		}
+	}
+	if _, err = replay.Show(Options{}); err == nil || !strings.Contains(err.Error(), "no scripted answer") {
+		t.Errorf("after the script: err = %v, want no scripted answer", err)
+	}
+}
+
+func TestReplayMatching(t *testing.T) {
+	r := NewReplay([]Answer{
+		{DialogID: "export", Paths: []string{"/out/report.pdf"}},
+		{Title: "Open", Paths: []string{"/in/titled.txt"}},
+		{Paths: []string{"/in/first.txt"}},
+		{Paths: []string{"/in/second.txt"}, FilterIndex: 2},
+		{DialogID: "export", Canceled: true, Paths: []string{"/ignored"}},
+	})
+	tests := []struct {
+		opts Options
+		path string
+		err  error
+	}{
+		{Options{Title: "Import"}, "/in/first.txt", nil},
+		{Options{DialogID: "export", Title: "Export"}, "/out/report.pdf", nil},
+		{Options{Title: "Open"}, "/in/titled.txt", nil},
+		{Options{Title: "Open"}, "/in/second.txt", nil},
+		{Options{DialogID: "export"}, "", ErrCanceled},
+		{Options{}, "", nil},
+	}
+	for i, tt := range tests {
+		res, err := r.Show(tt.opts)
+		if i == len(tests)-1 {
+			if err == nil || err == ErrCanceled {
+				t.Errorf("dialog %d: err = %v, want no scripted answer", i, err)
+			}
+			continue
+		}
+		if err != tt.err {
+			t.Errorf("dialog %d: err = %v, want %v", i, err, tt.err)
+			continue
+		}
+		if err == nil && res.Paths[0] != tt.path {
+			t.Errorf("dialog %d: got %q, want %q", i, res.Paths, tt.path)
+		}
+	}
+}
+
+func TestLoadReplayErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadReplay(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("missing file: err = %v", err)
+	}
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"paths": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadReplay(bad); err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("invalid script: err = %v, want one naming the file", err)
+	}
+}
+
+func TestLoadReplayFilterIndex(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.json")
+	data := `[{"paths": ["/x.png"]}, {"paths": ["/y.png"], "filterIndex": 0}, {"paths": ["/z.png"], "filterIndex": 2}]`
+	if err := os.WriteFile(script, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := LoadReplay(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []int{-1, 0, 2} {
+		res, err := r.Show(Options{})
+		if err != nil || res.FilterIndex != want {
+			t.Errorf("Show() = %+v, %v; want filter index %d", res, err, want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package winfileask
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kroppt/winfileask/lnk"
+)
+
+// isShortcut reports whether path names a shortcut (.lnk) file.
+func isShortcut(path string) bool {
+	return strings.EqualFold(filepath.Ext(baseName(path)), ".lnk")
+}
+
+// resolveShortcut returns the target of the shortcut at path. The absolute
+// target stored in the shortcut is used if it can be translated to a path
+// on this system, see shortcutTarget, and exists. Otherwise the relative
+// path stored in the shortcut is used if it exists, which also works on a
+// copy of the files moved elsewhere, and finally the translated target
+// even if it does not exist.
+func resolveShortcut(path string) (string, error) {
+	link, err := lnk.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", path, err)
+	}
+	var target string
+	var targetErr error
+	if link.Target == "" {
+		targetErr = fmt.Errorf("shortcut has no target path")
+	} else if target, targetErr = shortcutTarget(link.Target); targetErr == nil {
+		if _, err = os.Stat(target); err == nil {
+			return target, nil
+		}
+	}
+	if link.RelativePath != "" {
+		rel := filepath.FromSlash(strings.ReplaceAll(link.RelativePath, `\`, "/"))
+		rel = filepath.Join(filepath.Dir(path), rel)
+		if _, err = os.Stat(rel); err == nil {
+			return rel, nil
+		}
+	}
+	if targetErr != nil {
+		return "", fmt.Errorf("%s: %v", path, targetErr)
+	}
+	return target, nil
+}
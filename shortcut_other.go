@@ -0,0 +1,29 @@
+//go:build !windows
+
+package winfileask
+
+import (
+	"fmt"
+
+	"github.com/kroppt/winfileask/winpath"
+)
+
+// shortcutTarget translates target, the absolute Windows target of a
+// shortcut, to a path on this system. Under WSL, drive paths and paths in
+// the \\wsl$ share are translated as the WSL backend translates them.
+// Anywhere else a Windows path has no equivalent, and is an error.
+func shortcutTarget(target string) (string, error) {
+	if !IsWSL() {
+		return "", fmt.Errorf("shortcut target %q is a Windows path", target)
+	}
+	p, err := winpath.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	if !p.IsAbs() {
+		return "", fmt.Errorf("shortcut target %q is not an absolute path", target)
+	}
+	w := &WSL{}
+	conv := winpath.Converter{Style: winpath.WSL, MountRoot: w.mountRoot(), Distro: w.distro()}
+	return conv.ToPOSIX(target)
+}
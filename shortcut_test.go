@@ -0,0 +1,141 @@
+//go:build !windows
+
+package winfileask
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// makeShortcut returns a shell link whose LinkInfo holds the local base path
+// target and, if rel is not empty, whose RelativePath is rel. Strings are
+// stored in the system code page, so they must be ASCII.
+func makeShortcut(target, rel string) []byte {
+	const (
+		hasLinkInfo     = 0x2
+		hasRelativePath = 0x8
+	)
+	flags := uint32(hasLinkInfo)
+	if rel != "" {
+		flags |= hasRelativePath
+	}
+	le := binary.LittleEndian
+	data := make([]byte, 0x4C)
+	le.PutUint32(data, 0x4C)
+	copy(data[4:], []byte{0x01, 0x14, 0x02, 0, 0, 0, 0, 0, 0xC0, 0, 0, 0, 0, 0, 0, 0x46})
+	le.PutUint32(data[0x14:], flags)
+
+	// LinkInfo: a header, an empty VolumeID, the base path and an empty
+	// common path suffix.
+	volume := []byte{0x11, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 0x10, 0, 0, 0, 0}
+	info := make([]byte, 0x1C)
+	info = append(info, volume...)
+	baseOff := len(info)
+	info = append(info, target...)
+	info = append(info, 0)
+	suffixOff := len(info)
+	info = append(info, 0)
+	le.PutUint32(info, uint32(len(info)))
+	le.PutUint32(info[4:], 0x1C)
+	le.PutUint32(info[8:], 1)
+	le.PutUint32(info[0xC:], 0x1C)
+	le.PutUint32(info[0x10:], uint32(baseOff))
+	le.PutUint32(info[0x18:], uint32(suffixOff))
+	data = append(data, info...)
+
+	if rel != "" {
+		data = le.AppendUint16(data, uint16(len(rel)))
+		data = append(data, rel...)
+	}
+	return append(data, 0, 0, 0, 0)
+}
+
+// wslShare returns the Windows path of path through the \\wsl.localhost
+// share of distro.
+func wslShare(distro, path string) string {
+	return `\\wsl.localhost\` + distro + strings.ReplaceAll(path, "/", `\`)
+}
+
+func TestResolveShortcut(t *testing.T) {
+	dir := makeTree(t, "links/", "docs/report.txt", "docs/other.txt")
+	report := filepath.Join(dir, "docs", "report.txt")
+	other := filepath.Join(dir, "docs", "other.txt")
+	tests := []struct {
+		name   string
+		wsl    bool
+		target string
+		rel    string
+		want   string
+		err    string
+	}{
+		{"wsl target", true, wslShare("Ubuntu", report), `..\docs\other.txt`, report, ""},
+		{"wsl target without relative path", true, wslShare("Ubuntu", report), "", report, ""},
+		{"wsl missing target", true, wslShare("Ubuntu", report+".gone"), `..\docs\other.txt`, other, ""},
+		{"wsl drive target", true, `C:\Users\u\report.txt`, `..\docs\gone.txt`, "/mnt/c/Users/u/report.txt", ""},
+		{"wsl other distro", true, wslShare("Debian", report), `..\docs\other.txt`, other, ""},
+		{"wsl unc target", true, `\\server\share\report.txt`, `..\docs\gone.txt`, "", "no equivalent"},
+		{"wsl unexpanded target", true, `%USERPROFILE%\report.txt`, "", "", "not an absolute path"},
+		{"linux", false, `C:\Users\u\report.txt`, `..\docs\report.txt`, report, ""},
+		{"linux without relative path", false, `C:\Users\u\report.txt`, "", "", "is a Windows path"},
+		{"linux missing relative path", false, `C:\Users\u\report.txt`, `..\docs\gone.txt`, "", "is a Windows path"},
+		{"no target", false, "", `..\docs\report.txt`, report, ""},
+		{"nothing", false, "", "", "", "no target path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.wsl {
+				t.Setenv("WSL_DISTRO_NAME", "")
+				t.Setenv("WSL_INTEROP", "")
+				if IsWSL() {
+					t.Skip("running under WSL")
+				}
+			} else {
+				t.Setenv("WSL_DISTRO_NAME", "Ubuntu")
+			}
+			link := filepath.Join(dir, "links", "a.lnk")
+			if err := os.WriteFile(link, makeShortcut(tt.target, tt.rel), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := resolveShortcut(link)
+			switch {
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("resolveShortcut() = %q, %v; want an error containing %q", got, err, tt.err)
+			case tt.err == "" && (err != nil || got != tt.want):
+				t.Errorf("resolveShortcut() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveShortcutsOption(t *testing.T) {
+	t.Setenv("WSL_DISTRO_NAME", "Ubuntu")
+	dir := makeTree(t, "docs/report.txt", "plain.txt")
+	report := filepath.Join(dir, "docs", "report.txt")
+	link := filepath.Join(dir, "Report.LNK")
+	if err := os.WriteFile(link, makeShortcut(wslShare("Ubuntu", report), ""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	plain := filepath.Join(dir, "plain.txt")
+	d := NewDialoger(backendFunc(func(Options) (*Result, error) {
+		return &Result{Paths: []string{link, plain}, FilterIndex: -1}, nil
+	}))
+	res, err := d.OpenMultiple(Options{ResolveShortcuts: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Paths[0] != report || res.Paths[1] != plain || len(res.RawPaths) != 2 || res.RawPaths[0] != link {
+		t.Errorf("result = %q, raw %q", res.Paths, res.RawPaths)
+	}
+	if res, err = d.OpenMultiple(Options{}); err != nil || res.Paths[0] != link || res.RawPaths != nil {
+		t.Errorf("without ResolveShortcuts: %+v, %v", res, err)
+	}
+	if err = os.WriteFile(link, []byte("not a link"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = d.Open(Options{ResolveShortcuts: true}); err == nil || !strings.Contains(err.Error(), link) {
+		t.Errorf("broken shortcut: err = %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package winfileask
+
+import (
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+var procExpandEnvironmentStringsW = modkernel32.NewProc("ExpandEnvironmentStringsW")
+
+// shortcutTarget returns target, the target of a shortcut, with its
+// environment variables, such as %USERPROFILE%, expanded. A target that is
+// not absolute once expanded is an error.
+func shortcutTarget(target string) (string, error) {
+	expanded, err := expandEnvironmentStrings(target)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(expanded) {
+		return "", fmt.Errorf("shortcut target %q is not an absolute path", target)
+	}
+	return expanded, nil
+}
+
+// expandEnvironmentStrings replaces the %VARIABLE% references in s with
+// their values, as ExpandEnvironmentStringsW does. Undefined variables are
+// left as they are.
+func expandEnvironmentStrings(s string) (string, error) {
+	p, err := syscall.UTF16PtrFromString(s)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]uint16, syscall.MAX_PATH)
+	for {
+		n, _, err := procExpandEnvironmentStringsW.Call(uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+		if n == 0 {
+			return "", err
+		}
+		if int(n) <= len(buf) {
+			return syscall.UTF16ToString(buf[:n]), nil
+		}
+		buf = make([]uint16, n)
+	}
+}
@@ -0,0 +1,30 @@
+package winfileask
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShortcutTarget(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("WINFILEASK_TEST_DIR", dir)
+	tests := []struct {
+		in, want string
+	}{
+		{`C:\dir\file.txt`, `C:\dir\file.txt`},
+		{`\\server\share\file.txt`, `\\server\share\file.txt`},
+		{`%WINFILEASK_TEST_DIR%\file.txt`, filepath.Join(dir, "file.txt")},
+	}
+	for _, tt := range tests {
+		if got, err := shortcutTarget(tt.in); err != nil || got != tt.want {
+			t.Errorf("shortcutTarget(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	os.Unsetenv("WINFILEASK_UNDEFINED")
+	for _, in := range []string{`%WINFILEASK_UNDEFINED%\file.txt`, `dir\file.txt`} {
+		if got, err := shortcutTarget(in); err == nil {
+			t.Errorf("shortcutTarget(%q) = %q, want an error", in, got)
+		}
+	}
+}
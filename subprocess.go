@@ -0,0 +1,182 @@
+package winfileask
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Tool identifies the dialog program run by a Subprocess backend.
+type Tool int
+
+// The dialog programs supported by Subprocess.
+const (
+	Zenity Tool = iota
+	KDialog
+	Yad
+)
+
+// String returns the executable name of the tool.
+func (t Tool) String() string {
+	switch t {
+	case Zenity:
+		return "zenity"
+	case KDialog:
+		return "kdialog"
+	case Yad:
+		return "yad"
+	}
+	return fmt.Sprintf("Tool(%d)", int(t))
+}
+
+// Subprocess is a Backend that runs zenity, kdialog or yad and reads the
+// user's selection from its standard output.
+type Subprocess struct {
+	Tool Tool
+	// Path is the executable to run. If it is empty, the name of Tool is
+	// looked up in PATH.
+	Path string
+}
+
+// LookSubprocess searches PATH for zenity, kdialog and yad and returns a
+// Subprocess for the first one found. kdialog is preferred on KDE desktops.
+func LookSubprocess() (*Subprocess, error) {
+	tools := []Tool{Zenity, KDialog, Yad}
+	if strings.Contains(strings.ToUpper(os.Getenv("XDG_CURRENT_DESKTOP")), "KDE") {
+		tools = []Tool{KDialog, Zenity, Yad}
+	}
+	for _, t := range tools {
+		if path, err := exec.LookPath(t.String()); err == nil {
+			return &Subprocess{Tool: t, Path: path}, nil
+		}
+	}
+	return nil, fmt.Errorf("none of zenity, kdialog or yad found in PATH")
+}
+
+// Show implements Backend.
+func (s *Subprocess) Show(opts Options) (*Result, error) {
+	path := s.Path
+	if path == "" {
+		path = s.Tool.String()
+	}
+	var args []string
+	var err error
+	if s.Tool == KDialog {
+		args, err = kdialogArgs(opts)
+	} else {
+		args, err = zenityArgs(s.Tool, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, err
+		}
+		switch code := exitErr.ExitCode(); {
+		case code == 1, s.Tool == Yad && code == 252:
+			return nil, ErrCanceled
+		default:
+			msg := strings.TrimSpace(stderr.String())
+			return nil, fmt.Errorf("%s exited with status %d: %s", s.Tool, code, msg)
+		}
+	}
+	out := strings.TrimSuffix(stdout.String(), "\n")
+	if out == "" {
+		return nil, ErrCanceled
+	}
+	paths := []string{out}
+	if opts.Mode == ModeOpen && opts.Flags&AllowMultiSelect != 0 {
+		paths = strings.Split(out, "\n")
+	}
+	return &Result{Paths: paths, FilterIndex: -1}, nil
+}
+
+// startPath returns the path a dialog program starts at. Directories are
+// given a trailing separator so they are not mistaken for file names.
+func startPath(opts Options) string {
+	dir := opts.InitialDir
+	if opts.InitialFileName != "" && opts.Mode != ModeFolder {
+		return filepath.Join(dir, opts.InitialFileName)
+	}
+	if dir != "" && !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return dir
+}
+
+func zenityArgs(t Tool, opts Options) ([]string, error) {
+	args := []string{"--file-selection"}
+	if t == Yad {
+		args = []string{"--file"}
+	}
+	if opts.Title != "" {
+		args = append(args, "--title="+opts.Title)
+	}
+	switch opts.Mode {
+	case ModeSave:
+		args = append(args, "--save")
+		if opts.Flags&OverwritePrompt != 0 {
+			args = append(args, "--confirm-overwrite")
+		}
+	case ModeFolder:
+		args = append(args, "--directory")
+	default:
+		if opts.Flags&AllowMultiSelect != 0 {
+			args = append(args, "--multiple", "--separator=\n")
+		}
+	}
+	if start := startPath(opts); start != "" {
+		args = append(args, "--filename="+start)
+	}
+	if opts.Mode != ModeFolder {
+		var filters []string
+		var err error
+		if filters, err = opts.Filter.ZenityArgs(); err != nil {
+			return nil, err
+		}
+		args = append(args, filters...)
+	}
+	return args, nil
+}
+
+func kdialogArgs(opts Options) ([]string, error) {
+	var args []string
+	if opts.Title != "" {
+		args = append(args, "--title", opts.Title)
+	}
+	start := startPath(opts)
+	if start == "" {
+		start = "."
+	}
+	switch opts.Mode {
+	case ModeSave:
+		// kdialog always asks before overwriting an existing file.
+		args = append(args, "--getsavefilename", start)
+	case ModeFolder:
+		return append(args, "--getexistingdirectory", start), nil
+	default:
+		args = append(args, "--getopenfilename", start)
+	}
+	if len(opts.Filter) > 0 {
+		var filter string
+		var err error
+		if filter, err = opts.Filter.KDialogString(); err != nil {
+			return nil, err
+		}
+		args = append(args, filter)
+	}
+	if opts.Mode == ModeOpen && opts.Flags&AllowMultiSelect != 0 {
+		args = append(args, "--multiple", "--separate-output")
+	}
+	return args, nil
+}
@@ -0,0 +1,240 @@
+//go:build !windows
+
+package winfileask
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeTool is the script installed in place of zenity, kdialog and yad. It
+// writes its arguments, NUL terminated, to $FAKE_ARGS, prints $FAKE_OUT and
+// exits with $FAKE_EXIT.
+const fakeTool = `#!/bin/sh
+: > "$FAKE_ARGS"
+for a in "$@"; do printf '%s\0' "$a" >> "$FAKE_ARGS"; done
+printf '%s' "$FAKE_OUT"
+echo "fake failure" >&2
+exit ${FAKE_EXIT:-0}
+`
+
+// installFakeTools puts fake zenity, kdialog and yad executables in a
+// directory that becomes the only entry of PATH. It returns the file the
+// arguments of the last run are written to.
+func installFakeTools(t *testing.T, tools ...Tool) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, tool := range tools {
+		if err := os.WriteFile(filepath.Join(dir, tool.String()), []byte(fakeTool), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	argsFile := filepath.Join(t.TempDir(), "args")
+	t.Setenv("PATH", dir)
+	t.Setenv("FAKE_ARGS", argsFile)
+	t.Setenv("FAKE_OUT", "")
+	t.Setenv("FAKE_EXIT", "0")
+	return argsFile
+}
+
+func readFakeArgs(t *testing.T, argsFile string) []string {
+	t.Helper()
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+}
+
+func TestSubprocessArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		tool Tool
+		opts Options
+		want []string
+	}{
+		{
+			name: "zenity open",
+			tool: Zenity,
+			opts: Options{Mode: ModeOpen, Title: "Open", Filter: testFilter, InitialDir: "/home/u"},
+			want: []string{
+				"--file-selection", "--title=Open", "--filename=/home/u/",
+				"--file-filter=Text | *.[tT][xX][tT]",
+				"--file-filter=Images | *.[pP][nN][gG] *.[jJ][pP][gG]",
+			},
+		},
+		{
+			name: "zenity multiple",
+			tool: Zenity,
+			opts: Options{Mode: ModeOpen, Flags: AllowMultiSelect},
+			want: []string{"--file-selection", "--multiple", "--separator=\n"},
+		},
+		{
+			name: "zenity save",
+			tool: Zenity,
+			opts: Options{Mode: ModeSave, InitialDir: "/tmp", InitialFileName: "a.txt", Flags: SaveFlags},
+			want: []string{"--file-selection", "--save", "--confirm-overwrite", "--filename=/tmp/a.txt"},
+		},
+		{
+			name: "zenity save without overwrite prompt",
+			tool: Zenity,
+			opts: Options{Mode: ModeSave, InitialFileName: "a.txt"},
+			want: []string{"--file-selection", "--save", "--filename=a.txt"},
+		},
+		{
+			name: "zenity folder",
+			tool: Zenity,
+			opts: Options{Mode: ModeFolder, Title: "Pick", Filter: testFilter, InitialDir: "/srv"},
+			want: []string{"--file-selection", "--title=Pick", "--directory", "--filename=/srv/"},
+		},
+		{
+			name: "yad save",
+			tool: Yad,
+			opts: Options{Mode: ModeSave, Flags: OverwritePrompt},
+			want: []string{"--file", "--save", "--confirm-overwrite"},
+		},
+		{
+			name: "kdialog open",
+			tool: KDialog,
+			opts: Options{Mode: ModeOpen, Title: "Open", Filter: testFilter, InitialDir: "/home/u"},
+			want: []string{"--title", "Open", "--getopenfilename", "/home/u/", "Text (*.txt)\nImages (*.png *.jpg)"},
+		},
+		{
+			name: "kdialog multiple",
+			tool: KDialog,
+			opts: Options{Mode: ModeOpen, Flags: AllowMultiSelect},
+			want: []string{"--getopenfilename", ".", "--multiple", "--separate-output"},
+		},
+		{
+			name: "kdialog save",
+			tool: KDialog,
+			opts: Options{Mode: ModeSave, InitialDir: "/tmp", InitialFileName: "a.txt", Flags: SaveFlags},
+			want: []string{"--getsavefilename", "/tmp/a.txt"},
+		},
+		{
+			name: "kdialog folder",
+			tool: KDialog,
+			opts: Options{Mode: ModeFolder, Filter: testFilter},
+			want: []string{"--getexistingdirectory", "."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			argsFile := installFakeTools(t, tt.tool)
+			t.Setenv("FAKE_OUT", "/picked\n")
+			s := &Subprocess{Tool: tt.tool}
+			if _, err := s.Show(tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if got := readFakeArgs(t, argsFile); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubprocessResult(t *testing.T) {
+	installFakeTools(t, Zenity)
+	s := &Subprocess{Tool: Zenity}
+
+	t.Setenv("FAKE_OUT", "/a/one.txt\n")
+	res, err := s.Show(Options{Mode: ModeOpen})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/a/one.txt"}; !reflect.DeepEqual(res.Paths, want) || res.FilterIndex != -1 {
+		t.Errorf("single = %q, %d; want %q, -1", res.Paths, res.FilterIndex, want)
+	}
+
+	t.Setenv("FAKE_OUT", "/a/one.txt\n/a/two words.txt\n")
+	if res, err = s.Show(Options{Mode: ModeOpen, Flags: AllowMultiSelect}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/a/one.txt", "/a/two words.txt"}; !reflect.DeepEqual(res.Paths, want) {
+		t.Errorf("multiple = %q, want %q", res.Paths, want)
+	}
+
+	t.Setenv("FAKE_OUT", "")
+	if _, err = s.Show(Options{Mode: ModeOpen}); err != ErrCanceled {
+		t.Errorf("empty output: err = %v, want ErrCanceled", err)
+	}
+}
+
+func TestSeparatorInFilter(t *testing.T) {
+	for _, tool := range []Tool{KDialog, Zenity, Yad} {
+		args := installFakeTools(t, tool)
+		s := &Subprocess{Tool: tool}
+		_, err := s.Show(Options{Filter: FileFilter{{Name: "Text | logs", Pattern: "*.txt"}}})
+		if !errors.Is(err, ErrSeparator) {
+			t.Errorf("%s: err = %v, want ErrSeparator", tool, err)
+		}
+		if _, serr := os.Stat(args); serr == nil {
+			t.Errorf("%s was run with a filter it cannot express", tool)
+		}
+	}
+}
+
+func TestSubprocessExitCodes(t *testing.T) {
+	tests := []struct {
+		tool     Tool
+		code     string
+		canceled bool
+	}{
+		{Zenity, "1", true},
+		{KDialog, "1", true},
+		{Yad, "1", true},
+		{Yad, "252", true},
+		{Zenity, "252", false},
+		{Zenity, "5", false},
+		{KDialog, "2", false},
+		{Yad, "70", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool.String()+" "+tt.code, func(t *testing.T) {
+			installFakeTools(t, tt.tool)
+			t.Setenv("FAKE_OUT", "/ignored")
+			t.Setenv("FAKE_EXIT", tt.code)
+			_, err := (&Subprocess{Tool: tt.tool}).Show(Options{Mode: ModeOpen})
+			switch {
+			case tt.canceled && err != ErrCanceled:
+				t.Errorf("err = %v, want ErrCanceled", err)
+			case !tt.canceled && (err == nil || errors.Is(err, ErrCanceled)):
+				t.Errorf("err = %v, want a failure", err)
+			case !tt.canceled && !strings.Contains(err.Error(), "fake failure"):
+				t.Errorf("err = %v, want the standard error of the tool", err)
+			}
+		})
+	}
+}
+
+func TestLookSubprocess(t *testing.T) {
+	tests := []struct {
+		desktop string
+		tools   []Tool
+		want    Tool
+	}{
+		{"GNOME", []Tool{Zenity, KDialog, Yad}, Zenity},
+		{"KDE", []Tool{Zenity, KDialog, Yad}, KDialog},
+		{"KDE", []Tool{Zenity, Yad}, Zenity},
+		{"XFCE", []Tool{Yad}, Yad},
+	}
+	for _, tt := range tests {
+		installFakeTools(t, tt.tools...)
+		t.Setenv("XDG_CURRENT_DESKTOP", tt.desktop)
+		s, err := LookSubprocess()
+		if err != nil {
+			t.Fatalf("%s %v: %v", tt.desktop, tt.tools, err)
+		}
+		if s.Tool != tt.want {
+			t.Errorf("%s %v: tool = %v, want %v", tt.desktop, tt.tools, s.Tool, tt.want)
+		}
+	}
+	installFakeTools(t)
+	if _, err := LookSubprocess(); err == nil {
+		t.Error("LookSubprocess with an empty PATH succeeded")
+	}
+}
@@ -0,0 +1,492 @@
+package winfileask
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// TUI is a Backend that draws an interactive file picker on a terminal, for
+// SSH and other sessions without a graphical desktop.
+//
+// Up and Down move the highlight, Enter opens the highlighted directory or
+// picks the highlighted file, Left or Backspace goes to the parent directory,
+// Space marks files when AllowMultiSelect is set, Tab cycles through the
+// filters, Ctrl-A toggles hidden files and Esc or Ctrl-C cancels. In
+// ModeSave typed characters edit the file name and Enter saves under it, but
+// once the cursor keys have moved the highlight, Enter opens the highlighted
+// directory, keeping the name for it, or saves over the highlighted file. In
+// ModeFolder the "." entry picks the current directory.
+type TUI struct {
+	// In is read for key presses. If it is nil, os.Stdin is used. If it is a
+	// terminal, it is put into raw mode while the picker is shown.
+	In io.Reader
+	// Out is where the picker is drawn. If it is nil, os.Stdout is used.
+	Out io.Writer
+	// Height is the number of entries shown at once. If it is zero, it is
+	// derived from the terminal size.
+	Height int
+}
+
+// The keys understood by the picker.
+type key int
+
+const (
+	keyNone key = iota
+	keyRune
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyBackspace
+	keyTab
+	keySpace
+	keyHidden
+	keyCancel
+)
+
+// tuiEntry is a line of the directory listing.
+type tuiEntry struct {
+	name string
+	dir  bool
+}
+
+// picker is the state of a TUI while it is shown.
+type picker struct {
+	opts    Options
+	in      *bufio.Reader
+	out     io.Writer
+	height  int
+	dir     string
+	entries []tuiEntry
+	cursor  int
+	top     int
+	filter  int
+	hidden  bool
+	marked  map[string]bool
+	name    []rune
+	// naming is set while the file name, rather than the listing, has the
+	// focus in ModeSave.
+	naming  bool
+	message string
+}
+
+// Show implements Backend.
+func (t *TUI) Show(opts Options) (*Result, error) {
+	in, out := t.In, t.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+	height := t.Height
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return nil, err
+		}
+		defer term.Restore(int(f.Fd()), state)
+	}
+	if f, ok := out.(*os.File); ok && height == 0 {
+		if _, rows, err := term.GetSize(int(f.Fd())); err == nil {
+			height = rows - 8
+		}
+	}
+	if height <= 0 {
+		height = 20
+	}
+	dir := opts.startDir()
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	p := &picker{
+		opts:   opts,
+		in:     bufio.NewReader(in),
+		out:    out,
+		height: height,
+		dir:    dir,
+		hidden: opts.Flags&ForceShowHidden != 0,
+		marked: make(map[string]bool),
+		name:   []rune(opts.InitialFileName),
+		naming: opts.Mode == ModeSave,
+	}
+	if len(opts.Filter) == 0 || opts.Mode == ModeFolder {
+		p.filter = -1
+	}
+	if err = p.load(); err != nil {
+		return nil, err
+	}
+	// Use the alternate screen so the terminal is left as it was.
+	fmt.Fprint(out, "\x1b[?1049h")
+	defer fmt.Fprint(out, "\x1b[?1049l")
+	return p.run()
+}
+
+func (p *picker) run() (*Result, error) {
+	for {
+		p.render()
+		k, r, err := p.readKey()
+		if err == io.EOF {
+			return nil, ErrCanceled
+		} else if err != nil {
+			return nil, err
+		}
+		p.message = ""
+		switch k {
+		case keyCancel:
+			return nil, ErrCanceled
+		case keyUp, keyDown, keyPageUp, keyPageDown, keyHome, keyEnd:
+			p.naming = false
+			switch k {
+			case keyUp:
+				p.move(-1)
+			case keyDown:
+				p.move(1)
+			case keyPageUp:
+				p.move(-p.height)
+			case keyPageDown:
+				p.move(p.height)
+			case keyHome:
+				p.move(-len(p.entries))
+			case keyEnd:
+				p.move(len(p.entries))
+			}
+		case keyLeft:
+			p.chdir("..")
+		case keyRight:
+			if e, ok := p.current(); ok && e.dir {
+				p.chdir(e.name)
+			}
+		case keyBackspace:
+			if p.opts.Mode == ModeSave && len(p.name) > 0 {
+				p.name = p.name[:len(p.name)-1]
+				p.naming = true
+			} else {
+				p.chdir("..")
+			}
+		case keyTab:
+			if p.filter >= 0 {
+				p.filter = (p.filter + 1) % len(p.opts.Filter)
+				p.load()
+			}
+		case keyHidden:
+			p.hidden = !p.hidden
+			p.load()
+		case keySpace:
+			if p.opts.Mode == ModeSave {
+				p.name = append(p.name, ' ')
+				p.naming = true
+			} else if e, ok := p.current(); ok && !e.dir && p.opts.Flags&AllowMultiSelect != 0 {
+				path := filepath.Join(p.dir, e.name)
+				p.marked[path] = !p.marked[path]
+				p.move(1)
+			}
+		case keyRune:
+			if p.opts.Mode == ModeSave {
+				p.name = append(p.name, r)
+				p.naming = true
+			}
+		case keyEnter:
+			if res, err := p.enter(); res != nil || err != nil {
+				return res, err
+			}
+		}
+	}
+}
+
+// enter handles the Enter key and returns a Result once the user has picked
+// something.
+func (p *picker) enter() (*Result, error) {
+	if p.opts.Mode == ModeSave && p.naming && len(p.name) > 0 {
+		return p.save()
+	}
+	e, ok := p.current()
+	if !ok {
+		return nil, nil
+	}
+	switch {
+	case e.name == "." && p.opts.Mode == ModeFolder:
+		return p.result(p.dir), nil
+	case e.dir:
+		p.chdir(e.name)
+		// The name is kept for the new directory.
+		p.naming = p.opts.Mode == ModeSave
+	case p.opts.Mode == ModeSave:
+		p.name = []rune(e.name)
+		return p.save()
+	default:
+		var paths []string
+		for path, marked := range p.marked {
+			if marked {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			paths = []string{filepath.Join(p.dir, e.name)}
+		}
+		for _, path := range paths {
+			if err := p.opts.Extensions.Check(path); err != nil {
+				p.message = err.Error()
+				return nil, nil
+			}
+		}
+		sort.Strings(paths)
+		return p.result(paths...), nil
+	}
+	return nil, nil
+}
+
+// save validates the typed file name, asking before overwriting an existing
+// file when OverwritePrompt is set.
+func (p *picker) save() (*Result, error) {
+	path := string(p.name)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.dir, path)
+	}
+	if !p.opts.allowed(path) {
+		p.message = "You can only save in the allowed folders."
+		return nil, nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil || !fi.IsDir() {
+		if err := p.opts.Extensions.Check(path); err != nil {
+			p.message = err.Error()
+			return nil, nil
+		}
+	}
+	switch {
+	case err == nil && fi.IsDir():
+		p.name = nil
+		p.chdir(path)
+		return nil, nil
+	case err == nil && p.opts.Flags&OverwritePrompt != 0:
+		p.message = filepath.Base(path) + " already exists. Do you want to replace it? [y/N]"
+		p.render()
+		p.message = ""
+		k, r, err := p.readKey()
+		if err == io.EOF {
+			return nil, ErrCanceled
+		} else if err != nil || k != keyRune || (r != 'y' && r != 'Y') {
+			return nil, err
+		}
+	case os.IsNotExist(err) && p.opts.Flags&PathMustExist != 0:
+		if _, err := os.Stat(filepath.Dir(path)); err != nil {
+			p.message = "The path " + filepath.Dir(path) + " does not exist."
+			return nil, nil
+		}
+	}
+	return p.result(path), nil
+}
+
+func (p *picker) result(paths ...string) *Result {
+	return &Result{Paths: paths, FilterIndex: p.filter}
+}
+
+func (p *picker) current() (tuiEntry, bool) {
+	if p.cursor < 0 || p.cursor >= len(p.entries) {
+		return tuiEntry{}, false
+	}
+	return p.entries[p.cursor], true
+}
+
+func (p *picker) move(n int) {
+	p.cursor += n
+	if p.cursor >= len(p.entries) {
+		p.cursor = len(p.entries) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+	if p.cursor < p.top {
+		p.top = p.cursor
+	}
+	if p.cursor >= p.top+p.height {
+		p.top = p.cursor - p.height + 1
+	}
+}
+
+func (p *picker) chdir(name string) {
+	dir := name
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(p.dir, name)
+	}
+	if !p.opts.allowed(dir) {
+		p.message = "You can only choose from the allowed folders."
+		return
+	}
+	prev := p.dir
+	p.dir = dir
+	if err := p.load(); err != nil {
+		p.dir = prev
+		p.load()
+		p.message = err.Error()
+	}
+}
+
+// load reads the current directory, keeping directories, hidden entries and
+// files matching the current filter as configured.
+func (p *picker) load() error {
+	files, err := os.ReadDir(p.dir)
+	if err != nil {
+		return err
+	}
+	var entries []tuiEntry
+	if p.opts.Mode == ModeFolder {
+		entries = append(entries, tuiEntry{name: ".", dir: true})
+	}
+	if parent := filepath.Dir(p.dir); parent != p.dir && p.opts.allowed(parent) {
+		entries = append(entries, tuiEntry{name: "..", dir: true})
+	}
+	n := len(entries)
+	for _, f := range files {
+		name := f.Name()
+		if !p.hidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		dir := f.IsDir()
+		if f.Type()&os.ModeSymlink != 0 {
+			if fi, err := os.Stat(filepath.Join(p.dir, name)); err == nil {
+				dir = fi.IsDir()
+			}
+		}
+		if !dir && (p.opts.Mode == ModeFolder || (p.filter >= 0 && !p.opts.Filter[p.filter].Match(name))) {
+			continue
+		}
+		entries = append(entries, tuiEntry{name: name, dir: dir})
+	}
+	rest := entries[n:]
+	sort.Slice(rest, func(i, j int) bool {
+		if rest[i].dir != rest[j].dir {
+			return rest[i].dir
+		}
+		return strings.ToLower(rest[i].name) < strings.ToLower(rest[j].name)
+	})
+	p.entries = entries
+	p.cursor, p.top = 0, 0
+	return nil
+}
+
+func (p *picker) render() {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "%s\r\n%s\r\n\r\n", p.opts.title(), p.dir)
+	for i := p.top; i < len(p.entries) && i < p.top+p.height; i++ {
+		e := p.entries[i]
+		cursor, mark, suffix := "  ", " ", ""
+		if i == p.cursor {
+			cursor = "> "
+		}
+		if p.marked[filepath.Join(p.dir, e.name)] {
+			mark = "*"
+		}
+		if e.dir {
+			suffix = string(filepath.Separator)
+		}
+		fmt.Fprintf(&b, "%s%s%s%s\r\n", cursor, mark, e.name, suffix)
+	}
+	b.WriteString("\r\n")
+	if p.filter >= 0 {
+		f := p.opts.Filter[p.filter]
+		fmt.Fprintf(&b, "Type: %s (%s)\r\n", f.Name, f.Pattern)
+	}
+	if p.opts.Mode == ModeSave {
+		cursor := "  "
+		if p.naming {
+			cursor = "> "
+		}
+		fmt.Fprintf(&b, "%sName: %s\r\n", cursor, string(p.name))
+	}
+	if p.message != "" {
+		fmt.Fprintf(&b, "%s\r\n", p.message)
+	}
+	b.WriteString("Enter: open/choose  Backspace: up  Tab: type  Ctrl-A: hidden  Esc: cancel")
+	if p.opts.Mode == ModeOpen && p.opts.Flags&AllowMultiSelect != 0 {
+		b.WriteString("  Space: mark")
+	}
+	io.WriteString(p.out, b.String())
+}
+
+// readKey reads a key press, decoding the escape sequences terminals send
+// for cursor keys. A lone Esc cancels.
+func (p *picker) readKey() (key, rune, error) {
+	r, _, err := p.in.ReadRune()
+	if err != nil {
+		return keyNone, 0, err
+	}
+	switch r {
+	case '\r', '\n':
+		return keyEnter, r, nil
+	case 0x7f, 0x08:
+		return keyBackspace, r, nil
+	case '\t':
+		return keyTab, r, nil
+	case ' ':
+		return keySpace, r, nil
+	case 0x01:
+		return keyHidden, r, nil
+	case 0x03, 0x04:
+		return keyCancel, r, nil
+	case 0x1b:
+		return p.readEscape()
+	}
+	if r < ' ' {
+		return keyNone, r, nil
+	}
+	return keyRune, r, nil
+}
+
+func (p *picker) readEscape() (key, rune, error) {
+	if p.in.Buffered() == 0 {
+		return keyCancel, 0x1b, nil
+	}
+	if b, _ := p.in.ReadByte(); b != '[' && b != 'O' {
+		p.in.UnreadByte()
+		return keyCancel, 0x1b, nil
+	}
+	var seq []byte
+	for {
+		b, err := p.in.ReadByte()
+		if err != nil {
+			return keyNone, 0, err
+		}
+		seq = append(seq, b)
+		if b >= 0x40 && b <= 0x7e {
+			break
+		}
+	}
+	switch string(seq) {
+	case "A":
+		return keyUp, 0, nil
+	case "B":
+		return keyDown, 0, nil
+	case "C":
+		return keyRight, 0, nil
+	case "D":
+		return keyLeft, 0, nil
+	case "H", "1~", "7~":
+		return keyHome, 0, nil
+	case "F", "4~", "8~":
+		return keyEnd, 0, nil
+	case "5~":
+		return keyPageUp, 0, nil
+	case "6~":
+		return keyPageDown, 0, nil
+	}
+	return keyNone, 0, nil
+}
@@ -0,0 +1,218 @@
+package winfileask
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Key presses as a terminal sends them.
+const (
+	up    = "\x1b[A"
+	down  = "\x1b[B"
+	right = "\x1b[C"
+	left  = "\x1b[D"
+	enter = "\r"
+	tab   = "\t"
+	space = " "
+	ctrlA = "\x01"
+	bs    = "\x7f"
+	esc   = "\x1b"
+)
+
+// makeTree creates files and directories, given as slash separated paths
+// relative to a new temporary directory, and returns the directory. Paths
+// ending in a slash are directories.
+func makeTree(t *testing.T, paths ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, p := range paths {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		if strings.HasSuffix(p, "/") {
+			if err := os.MkdirAll(full, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(p), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// runTUI shows a TUI reading keys and returns its result and what it drew.
+func runTUI(t *testing.T, opts Options, keys ...string) (*Result, string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	tui := &TUI{In: strings.NewReader(strings.Join(keys, "")), Out: &out, Height: 10}
+	res, err := tui.Show(opts)
+	return res, out.String(), err
+}
+
+func TestTUIOpen(t *testing.T) {
+	dir := makeTree(t, "a.txt", "b.txt", "c.png", ".hidden.txt", "sub/d.txt", "sub/e.png")
+	join := func(names ...string) []string {
+		paths := make([]string, len(names))
+		for i, name := range names {
+			paths[i] = filepath.Join(dir, filepath.FromSlash(name))
+		}
+		return paths
+	}
+	filter := FileFilter{{Name: "Text", Pattern: "*.txt"}, {Name: "Images", Pattern: "*.png"}}
+	// The listing starts with "..", then the directories, then the files.
+	tests := []struct {
+		name  string
+		opts  Options
+		keys  []string
+		paths []string
+		index int
+	}{
+		{"first file", Options{}, []string{down, down, enter}, join("a.txt"), -1},
+		{"up and down", Options{}, []string{down, down, down, down, up, enter}, join("b.txt"), -1},
+		{"past the end", Options{}, []string{strings.Repeat(down, 10), enter}, join("c.png"), -1},
+		{"into a directory", Options{}, []string{down, enter, down, down, enter}, join("sub/e.png"), -1},
+		{"right and left", Options{}, []string{down, right, left, down, down, enter}, join("a.txt"), -1},
+		{"backspace goes up", Options{}, []string{down, right, bs, down, down, enter}, join("a.txt"), -1},
+		{"first filter", Options{Filter: filter}, []string{down, down, down, enter}, join("b.txt"), 0},
+		{"tab", Options{Filter: filter}, []string{tab, down, down, enter}, join("c.png"), 1},
+		{"tab wraps", Options{Filter: filter}, []string{tab, tab, down, down, down, enter}, join("b.txt"), 0},
+		{"hidden", Options{}, []string{ctrlA, down, down, enter}, join(".hidden.txt"), -1},
+		{"hidden toggled twice", Options{}, []string{ctrlA, ctrlA, down, down, enter}, join("a.txt"), -1},
+		{"forced hidden", Options{Flags: ForceShowHidden}, []string{down, down, enter}, join(".hidden.txt"), -1},
+		{
+			"multiple",
+			Options{Flags: AllowMultiSelect},
+			[]string{down, down, space, down, space, enter},
+			join("a.txt", "c.png"),
+			-1,
+		},
+		{"space without multiple", Options{}, []string{down, down, space, enter}, join("a.txt"), -1},
+		{"space on a directory", Options{Flags: AllowMultiSelect}, []string{down, space, enter, down, enter}, join("sub/d.txt"), -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.InitialDir = dir
+			res, _, err := runTUI(t, tt.opts, tt.keys...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res.Paths, tt.paths) || res.FilterIndex != tt.index {
+				t.Errorf("result = %q, %d; want %q, %d", res.Paths, res.FilterIndex, tt.paths, tt.index)
+			}
+		})
+	}
+}
+
+func TestTUIDraw(t *testing.T) {
+	dir := makeTree(t, "a.txt", "c.png", "sub/")
+	filter := FileFilter{{Name: "Text", Pattern: "*.txt"}, {Name: "Images", Pattern: "*.png"}}
+	_, out, err := runTUI(t, Options{Title: "Pick one", InitialDir: dir, Filter: filter}, tab, esc)
+	if err != ErrCanceled {
+		t.Fatalf("err = %v, want ErrCanceled", err)
+	}
+	for _, want := range []string{"Pick one", dir, ">  ..", "sub" + string(filepath.Separator), "Type: Text (*.txt)", "Type: Images (*.png)", "c.png"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if !strings.HasPrefix(out, "\x1b[?1049h") || !strings.HasSuffix(out, "\x1b[?1049l") {
+		t.Error("output is not drawn on the alternate screen")
+	}
+}
+
+func TestTUIFolder(t *testing.T) {
+	dir := makeTree(t, "a.txt", "sub/inner/")
+	res, _, err := runTUI(t, Options{Mode: ModeFolder, InitialDir: dir}, enter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{dir}; !reflect.DeepEqual(res.Paths, want) {
+		t.Errorf("paths = %q, want %q", res.Paths, want)
+	}
+	// The listing is ".", "..", then the directories without the files.
+	res, _, err = runTUI(t, Options{Mode: ModeFolder, InitialDir: dir}, down, down, enter, enter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "sub")}; !reflect.DeepEqual(res.Paths, want) {
+		t.Errorf("paths = %q, want %q", res.Paths, want)
+	}
+}
+
+func TestTUISave(t *testing.T) {
+	dir := makeTree(t, "a.txt", "sub/")
+	tests := []struct {
+		name string
+		opts Options
+		keys []string
+		path string
+		err  error
+	}{
+		{"typed name", Options{}, []string{"new.txt", enter}, "new.txt", nil},
+		{"edited name", Options{}, []string{"nex", bs, "w file.txt", enter}, "new file.txt", nil},
+		{"initial name", Options{InitialFileName: "draft.txt"}, []string{enter}, "draft.txt", nil},
+		{"replaced initial name", Options{InitialFileName: "ab"}, []string{bs, bs, "cd", enter}, "cd", nil},
+		{"picked name", Options{}, []string{down, down, enter}, "a.txt", nil},
+		{"picked name replaces the initial one", Options{InitialFileName: "r.txt"}, []string{down, down, enter}, "a.txt", nil},
+		{"highlighted directory", Options{InitialFileName: "r.txt"}, []string{down, enter, enter}, "sub/r.txt", nil},
+		{"highlighted directory with a typed name", Options{}, []string{"r.txt", down, enter, enter}, "sub/r.txt", nil},
+		{"highlighted directory and back up", Options{InitialFileName: "r.txt"}, []string{down, enter, left, enter}, "r.txt", nil},
+		{"name edited after moving", Options{InitialFileName: "r.txt"}, []string{down, "x", enter}, "r.txtx", nil},
+		{"highlighted parent", Options{InitialFileName: "r.txt"}, []string{down, enter, up, enter, enter}, "r.txt", nil},
+		{"into a directory", Options{}, []string{"sub", enter, "x.txt", enter}, "sub/x.txt", nil},
+		{"overwrite confirmed", Options{Flags: OverwritePrompt}, []string{"a.txt", enter, "y"}, "a.txt", nil},
+		{"overwrite declined", Options{Flags: OverwritePrompt}, []string{"a.txt", enter, "n"}, "", ErrCanceled},
+		{"overwrite declined then confirmed", Options{Flags: OverwritePrompt}, []string{"a.txt", enter, "n", enter, "Y"}, "a.txt", nil},
+		{"overwrite declined and renamed", Options{Flags: OverwritePrompt}, []string{"a.txt", enter, "n", bs, bs, bs, "md", enter}, "a.md", nil},
+		{"overwrite without prompt", Options{}, []string{"a.txt", enter}, "a.txt", nil},
+		{"missing directory", Options{Flags: PathMustExist}, []string{"no/x.txt", enter}, "", ErrCanceled},
+		{"canceled", Options{}, []string{"x", esc}, "", ErrCanceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Mode = ModeSave
+			tt.opts.InitialDir = dir
+			res, _, err := runTUI(t, tt.opts, tt.keys...)
+			if err != tt.err {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if want := []string{filepath.Join(dir, filepath.FromSlash(tt.path))}; !reflect.DeepEqual(res.Paths, want) {
+				t.Errorf("paths = %q, want %q", res.Paths, want)
+			}
+		})
+	}
+}
+
+func TestTUIPrompt(t *testing.T) {
+	dir := makeTree(t, "a.txt")
+	_, out, err := runTUI(t, Options{Mode: ModeSave, InitialDir: dir, Flags: OverwritePrompt}, "a.txt", enter)
+	if err != ErrCanceled {
+		t.Fatalf("err = %v, want ErrCanceled at the end of the input", err)
+	}
+	if !strings.Contains(out, "a.txt already exists. Do you want to replace it? [y/N]") {
+		t.Error("no overwrite prompt was shown")
+	}
+}
+
+func TestTUIStartDir(t *testing.T) {
+	dir := makeTree(t, "file")
+	for _, start := range []string{filepath.Join(dir, "missing"), filepath.Join(dir, "file")} {
+		res, out, err := runTUI(t, Options{InitialDir: start}, enter)
+		if err == nil || res != nil {
+			t.Errorf("start in %s: %v, %v; want an error", start, res, err)
+		}
+		if out != "" {
+			t.Errorf("start in %s: drew %q", start, out)
+		}
+	}
+}
@@ -1,18 +1,10 @@
 package winfileask
 
 import (
-	"fmt"
-	"strings"
-	"syscall"
+	"unicode/utf16"
 	"unsafe"
 )
 
-var (
-	modcomdlg32         = syscall.NewLazyDLL("comdlg32.dll")
-	procGetSaveFileName = modcomdlg32.NewProc("GetSaveFileNameW")
-	procGetOpenFileName = modcomdlg32.NewProc("GetOpenFileNameW")
-)
-
 // The flags for the Flags member of TagOFNA.
 const (
 	// AllowMultiSelect means the File Name list box allows multiple
@@ -445,29 +437,25 @@ type Filter struct {
 type FileFilter []Filter
 
 // ToRaw returns a uint16 pointer to the string representation of the filter.
+// The filter is checked with Validate first.
 func (ff *FileFilter) ToRaw() (*uint16, error) {
-	var sb strings.Builder
-	var ptr []uint16
+	var raw []uint16
 	var err error
-	for _, f := range *ff {
-		sb.WriteString(f.Name)
-		sb.WriteRune('|')
-		if strings.ContainsRune(f.Pattern, ' ') {
-			return nil, fmt.Errorf("pattern contains a space")
-		}
-		sb.WriteString(f.Pattern)
-		sb.WriteRune('|')
-	}
-	sb.WriteRune('|')
-	if ptr, err = syscall.UTF16FromString(sb.String()); err != nil {
+	if err = ff.Validate(); err != nil {
 		return nil, err
 	}
-	for i := range ptr {
-		if ptr[i] == uint16('|') {
-			ptr[i] = uint16(0)
-		}
+	for _, f := range *ff {
+		raw = append(raw, utf16.Encode([]rune(f.Name))...)
+		raw = append(raw, 0)
+		raw = append(raw, utf16.Encode([]rune(f.Pattern))...)
+		raw = append(raw, 0)
+	}
+	// The list ends with an empty string, so an empty filter is two NULs.
+	if len(raw) == 0 {
+		raw = append(raw, 0)
 	}
-	return &ptr[0], nil
+	raw = append(raw, 0)
+	return &raw[0], nil
 }
 
 // NewTagOFNA returns an initialized TagOFNA struct
@@ -477,7 +465,7 @@ func NewTagOFNA(parentHWND unsafe.Pointer, title string, filter FileFilter, init
 	lStructSize = uint32(unsafe.Sizeof(ofn))
 	var lpstrTitle *uint16
 	var err error
-	if lpstrTitle, err = syscall.UTF16PtrFromString(title); err != nil {
+	if lpstrTitle, err = utf16PtrFromString(title); err != nil {
 		return nil, err
 	}
 	var lpstrFilter *uint16
@@ -485,7 +473,7 @@ func NewTagOFNA(parentHWND unsafe.Pointer, title string, filter FileFilter, init
 		return nil, err
 	}
 	var lpstrInitialDir *uint16
-	if lpstrInitialDir, err = syscall.UTF16PtrFromString(initialDir); err != nil {
+	if lpstrInitialDir, err = utf16PtrFromString(initialDir); err != nil {
 		return nil, err
 	}
 	return &TagOFNA{
@@ -502,43 +490,3 @@ func NewTagOFNA(parentHWND unsafe.Pointer, title string, filter FileFilter, init
 		NFileExtension:  0, // set by system
 	}, nil
 }
-
-// GetOpenFileName creates an Open dialog box that lets the user specify the
-// drive, directory, and the name of a file or set of files to be opened.
-func GetOpenFileName(parentHWND unsafe.Pointer, title string, filter FileFilter, initialDir string) (string, bool, error) {
-	var ofn *TagOFNA
-	var err error
-	flags := FileMustExist | HideReadOnly | PathMustExist | NoChangeDir
-	if ofn, err = NewTagOFNA(parentHWND, title, filter, initialDir, flags); err != nil {
-		return "", false, err
-	}
-	buf := make([]uint16, 1024)
-	ofn.LpstrFile = &buf[0]
-	ofn.NMaxFile = 1024
-	ret, _, _ := procGetOpenFileName.Call(uintptr(unsafe.Pointer(ofn)))
-	if ret == 0 {
-		return "", false, nil
-	}
-	str := syscall.UTF16ToString(buf)
-	return str, true, nil
-}
-
-// GetSaveFileName creates a Save dialog box that lets the user specify the
-// drive, directory, and name of a file to save.
-func GetSaveFileName(parentHWND unsafe.Pointer, title string, filter FileFilter, initialDir string) (string, bool, error) {
-	var ofn *TagOFNA
-	var err error
-	flags := HideReadOnly | PathMustExist | NoChangeDir | OverwritePrompt
-	if ofn, err = NewTagOFNA(parentHWND, title, filter, initialDir, flags); err != nil {
-		return "", false, err
-	}
-	buf := make([]uint16, 1024)
-	ofn.LpstrFile = &buf[0]
-	ofn.NMaxFile = 1024
-	ret, _, _ := procGetSaveFileName.Call(uintptr(unsafe.Pointer(ofn)))
-	if ret == 0 {
-		return "", false, nil
-	}
-	str := syscall.UTF16ToString(buf)
-	return str, true, nil
-}
@@ -0,0 +1,37 @@
+package winfileask
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modcomdlg32              = syscall.NewLazyDLL("comdlg32.dll")
+	procGetSaveFileName      = modcomdlg32.NewProc("GetSaveFileNameW")
+	procGetOpenFileName      = modcomdlg32.NewProc("GetOpenFileNameW")
+	procCommDlgExtendedError = modcomdlg32.NewProc("CommDlgExtendedError")
+)
+
+// comdlg32 calls the functions of comdlg32.dll.
+type comdlg32 struct{}
+
+func (comdlg32) GetOpenFileName(ofn *TagOFNA) bool {
+	ret, _, _ := procGetOpenFileName.Call(uintptr(unsafe.Pointer(ofn)))
+	return ret != 0
+}
+
+func (comdlg32) GetSaveFileName(ofn *TagOFNA) bool {
+	ret, _, _ := procGetSaveFileName.Call(uintptr(unsafe.Pointer(ofn)))
+	return ret != 0
+}
+
+func (comdlg32) CommDlgExtendedError() uint32 {
+	ret, _, _ := procCommDlgExtendedError.Call()
+	return uint32(ret)
+}
+
+// systemFileName shows the dialog of GetOpenFileName or GetSaveFileName
+// when no Dialoger is installed: the common dialog box of comdlg32.dll.
+func systemFileName(parentHWND unsafe.Pointer, mode Mode, title string, filter FileFilter, initialDir string) (string, bool, error) {
+	return getFileName(comdlg32{}, parentHWND, mode, title, filter, initialDir)
+}
@@ -0,0 +1,251 @@
+// Package winfileasktest provides a scriptable winfileask.Dialoger for testing
+// code that shows file dialogs.
+//
+// A test creates a Mock, states the dialogs it expects in order and what each
+// should answer, and installs the mock as the default Dialoger:
+//
+//	m := winfileasktest.New(t)
+//	m.ExpectOpen().WithTitle("Open image").Return(`C:\pics\cat.png`)
+//	m.ExpectSave().Cancel()
+//	m.Install()
+//
+// Calls that were not expected, or that do not match the next expectation,
+// fail the test, and so do expectations left unmet when the test ends.
+package winfileasktest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kroppt/winfileask"
+)
+
+// ErrUnexpected is returned for calls the Mock did not expect.
+var ErrUnexpected = errors.New("winfileasktest: unexpected dialog")
+
+// Kind is the Dialoger method an Expectation is for.
+type Kind int
+
+// The Dialoger methods.
+const (
+	Open Kind = iota
+	OpenMultiple
+	Save
+	Folder
+)
+
+// String returns the name of the Dialoger method.
+func (k Kind) String() string {
+	switch k {
+	case Open:
+		return "Open"
+	case OpenMultiple:
+		return "OpenMultiple"
+	case Save:
+		return "Save"
+	case Folder:
+		return "Folder"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Expectation is an expected dialog and its scripted answer. Its methods
+// return the Expectation so they can be chained.
+type Expectation struct {
+	kind     Kind
+	matchers []func(winfileask.Options) error
+	result   *winfileask.Result
+	err      error
+	met      bool
+	opts     winfileask.Options
+}
+
+// WithTitle requires the dialog to have the given title.
+func (e *Expectation) WithTitle(title string) *Expectation {
+	return e.Match(func(opts winfileask.Options) error {
+		if opts.Title != title {
+			return fmt.Errorf("title is %q, want %q", opts.Title, title)
+		}
+		return nil
+	})
+}
+
+// WithFilter requires the dialog to have the given filter.
+func (e *Expectation) WithFilter(filter winfileask.FileFilter) *Expectation {
+	return e.Match(func(opts winfileask.Options) error {
+		if !reflect.DeepEqual(opts.Filter, filter) {
+			return fmt.Errorf("filter is %v, want %v", opts.Filter, filter)
+		}
+		return nil
+	})
+}
+
+// WithInitialDir requires the dialog to start in the given directory.
+func (e *Expectation) WithInitialDir(dir string) *Expectation {
+	return e.Match(func(opts winfileask.Options) error {
+		if opts.InitialDir != dir {
+			return fmt.Errorf("initial directory is %q, want %q", opts.InitialDir, dir)
+		}
+		return nil
+	})
+}
+
+// Match adds a custom requirement. f returns an error describing the
+// mismatch, or nil if the options are acceptable.
+func (e *Expectation) Match(f func(winfileask.Options) error) *Expectation {
+	e.matchers = append(e.matchers, f)
+	return e
+}
+
+// Return answers the dialog with the given paths.
+func (e *Expectation) Return(paths ...string) *Expectation {
+	e.result = &winfileask.Result{Paths: paths, FilterIndex: -1}
+	e.err = nil
+	return e
+}
+
+// ReturnResult answers the dialog with res.
+func (e *Expectation) ReturnResult(res *winfileask.Result) *Expectation {
+	e.result = res
+	e.err = nil
+	return e
+}
+
+// Cancel answers the dialog as if the user dismissed it.
+func (e *Expectation) Cancel() *Expectation {
+	return e.ReturnError(winfileask.ErrCanceled)
+}
+
+// ReturnError makes the dialog fail with err.
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.result = nil
+	e.err = err
+	return e
+}
+
+// Options returns the options the expected dialog was shown with, once it
+// has been met.
+func (e *Expectation) Options() winfileask.Options {
+	return e.opts
+}
+
+// Mock is a winfileask.Dialoger that answers dialogs from a list of
+// expectations, met in the order they were added.
+type Mock struct {
+	t            testing.TB
+	mu           sync.Mutex
+	expectations []*Expectation
+}
+
+// New returns a Mock that reports to t. Expectations still unmet when the
+// test finishes are reported as errors.
+func New(t testing.TB) *Mock {
+	m := &Mock{t: t}
+	t.Cleanup(m.AssertExpectations)
+	return m
+}
+
+// Install makes m the default Dialoger of package winfileask until the test
+// finishes.
+func (m *Mock) Install() {
+	prev := winfileask.SetDefault(m)
+	m.t.Cleanup(func() { winfileask.SetDefault(prev) })
+}
+
+// ExpectOpen expects a call to Open.
+func (m *Mock) ExpectOpen() *Expectation {
+	return m.expect(Open)
+}
+
+// ExpectOpenMultiple expects a call to OpenMultiple.
+func (m *Mock) ExpectOpenMultiple() *Expectation {
+	return m.expect(OpenMultiple)
+}
+
+// ExpectSave expects a call to Save.
+func (m *Mock) ExpectSave() *Expectation {
+	return m.expect(Save)
+}
+
+// ExpectFolder expects a call to Folder.
+func (m *Mock) ExpectFolder() *Expectation {
+	return m.expect(Folder)
+}
+
+func (m *Mock) expect(kind Kind) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := &Expectation{kind: kind, err: winfileask.ErrCanceled}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// AssertExpectations reports every expectation that has not been met.
+func (m *Mock) AssertExpectations() {
+	m.t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.expectations {
+		if !e.met {
+			m.t.Errorf("winfileasktest: expected %s dialog was not shown", e.kind)
+		}
+	}
+}
+
+// Open implements winfileask.Dialoger.
+func (m *Mock) Open(opts winfileask.Options) (*winfileask.Result, error) {
+	return m.call(Open, opts)
+}
+
+// OpenMultiple implements winfileask.Dialoger.
+func (m *Mock) OpenMultiple(opts winfileask.Options) (*winfileask.Result, error) {
+	return m.call(OpenMultiple, opts)
+}
+
+// Save implements winfileask.Dialoger.
+func (m *Mock) Save(opts winfileask.Options) (*winfileask.Result, error) {
+	return m.call(Save, opts)
+}
+
+// Folder implements winfileask.Dialoger.
+func (m *Mock) Folder(opts winfileask.Options) (*winfileask.Result, error) {
+	return m.call(Folder, opts)
+}
+
+func (m *Mock) call(kind Kind, opts winfileask.Options) (*winfileask.Result, error) {
+	m.t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var e *Expectation
+	for _, x := range m.expectations {
+		if !x.met {
+			e = x
+			break
+		}
+	}
+	if e == nil {
+		m.t.Errorf("winfileasktest: unexpected %s dialog %q", kind, opts.Title)
+		return nil, ErrUnexpected
+	}
+	if e.kind != kind {
+		m.t.Errorf("winfileasktest: got %s dialog %q, want %s", kind, opts.Title, e.kind)
+		return nil, ErrUnexpected
+	}
+	for _, match := range e.matchers {
+		if err := match(opts); err != nil {
+			m.t.Errorf("winfileasktest: %s dialog: %v", kind, err)
+			return nil, ErrUnexpected
+		}
+	}
+	e.met = true
+	e.opts = opts
+	if e.err != nil {
+		return nil, e.err
+	}
+	res := *e.result
+	res.Paths = append([]string(nil), e.result.Paths...)
+	return &res, nil
+}
@@ -0,0 +1,224 @@
+package winfileasktest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kroppt/winfileask"
+)
+
+// fakeTB records what a Mock reports instead of failing the real test.
+type fakeTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+// finish runs the cleanups as the end of a test would.
+func (f *fakeTB) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+	f.cleanups = nil
+}
+
+// checkErrors fails t unless tb reported exactly one error per substring in
+// want, in order.
+func checkErrors(t *testing.T, tb *fakeTB, want ...string) {
+	t.Helper()
+	if len(tb.errors) != len(want) {
+		t.Fatalf("reported %q, want %d errors", tb.errors, len(want))
+	}
+	for i, w := range want {
+		if !strings.Contains(tb.errors[i], w) {
+			t.Errorf("error %d = %q, want it to contain %q", i, tb.errors[i], w)
+		}
+	}
+}
+
+var images = winfileask.FileFilter{{Name: "Images", Pattern: "*.png"}}
+
+func TestMockInOrder(t *testing.T) {
+	tb := &fakeTB{}
+	m := New(tb)
+	open := m.ExpectOpen().WithTitle("Open image").WithFilter(images).Return(`C:\pics\cat.png`)
+	m.ExpectOpenMultiple().ReturnResult(&winfileask.Result{Paths: []string{"a", "b"}, FilterIndex: 1})
+	m.ExpectSave().WithInitialDir(`C:\out`).Cancel()
+	m.ExpectFolder().ReturnError(errors.New("no access"))
+
+	opts := winfileask.Options{Title: "Open image", Filter: images}
+	res, err := m.Open(opts)
+	if err != nil || !reflect.DeepEqual(res.Paths, []string{`C:\pics\cat.png`}) || res.FilterIndex != -1 {
+		t.Errorf("Open = %+v, %v", res, err)
+	}
+	if !reflect.DeepEqual(open.Options(), opts) {
+		t.Errorf("Options() = %+v, want %+v", open.Options(), opts)
+	}
+	res, err = m.OpenMultiple(winfileask.Options{})
+	if err != nil || !reflect.DeepEqual(res.Paths, []string{"a", "b"}) || res.FilterIndex != 1 {
+		t.Errorf("OpenMultiple = %+v, %v", res, err)
+	}
+	res.Paths[0] = "changed"
+	if _, err = m.Save(winfileask.Options{InitialDir: `C:\out`}); err != winfileask.ErrCanceled {
+		t.Errorf("Save: err = %v, want ErrCanceled", err)
+	}
+	if _, err = m.Folder(winfileask.Options{}); err == nil || err.Error() != "no access" {
+		t.Errorf("Folder: err = %v, want no access", err)
+	}
+	tb.finish()
+	checkErrors(t, tb)
+}
+
+func TestMockDefaultAnswer(t *testing.T) {
+	tb := &fakeTB{}
+	m := New(tb)
+	m.ExpectOpen()
+	if _, err := m.Open(winfileask.Options{}); err != winfileask.ErrCanceled {
+		t.Errorf("err = %v, want ErrCanceled", err)
+	}
+	tb.finish()
+	checkErrors(t, tb)
+}
+
+func TestMockKindMismatch(t *testing.T) {
+	tb := &fakeTB{}
+	m := New(tb)
+	m.ExpectOpen()
+	if _, err := m.Save(winfileask.Options{Title: "Export"}); err != ErrUnexpected {
+		t.Errorf("err = %v, want ErrUnexpected", err)
+	}
+	tb.finish()
+	checkErrors(t, tb, `got Save dialog "Export", want Open`, "expected Open dialog was not shown")
+}
+
+func TestMockOutOfOrder(t *testing.T) {
+	tb := &fakeTB{}
+	m := New(tb)
+	m.ExpectOpen().Return("a")
+	m.ExpectFolder().Return("dir")
+	if _, err := m.Folder(winfileask.Options{}); err != ErrUnexpected {
+		t.Errorf("Folder first: err = %v, want ErrUnexpected", err)
+	}
+	if res, err := m.Open(winfileask.Options{}); err != nil || res.Paths[0] != "a" {
+		t.Errorf("Open = %v, %v", res, err)
+	}
+	if res, err := m.Folder(winfileask.Options{}); err != nil || res.Paths[0] != "dir" {
+		t.Errorf("Folder = %v, %v", res, err)
+	}
+	tb.finish()
+	checkErrors(t, tb, "got Folder dialog")
+}
+
+func TestMockUnexpected(t *testing.T) {
+	tb := &fakeTB{}
+	m := New(tb)
+	m.ExpectOpen().Return("a")
+	m.Open(winfileask.Options{})
+	if _, err := m.Open(winfileask.Options{Title: "Again"}); err != ErrUnexpected {
+		t.Errorf("err = %v, want ErrUnexpected", err)
+	}
+	tb.finish()
+	checkErrors(t, tb, `unexpected Open dialog "Again"`)
+}
+
+func TestMockMatchers(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(e *Expectation)
+		opts   winfileask.Options
+		err    string
+	}{
+		{"title", func(e *Expectation) { e.WithTitle("Open") }, winfileask.Options{Title: "Load"}, `title is "Load", want "Open"`},
+		{"filter", func(e *Expectation) { e.WithFilter(images) }, winfileask.Options{}, "filter is"},
+		{"initial dir", func(e *Expectation) { e.WithInitialDir("/a") }, winfileask.Options{InitialDir: "/b"}, `initial directory is "/b", want "/a"`},
+		{"custom", func(e *Expectation) {
+			e.Match(func(opts winfileask.Options) error {
+				if opts.Flags&winfileask.FileMustExist == 0 {
+					return errors.New("file need not exist")
+				}
+				return nil
+			})
+		}, winfileask.Options{}, "Open dialog: file need not exist"},
+		{"second matcher", func(e *Expectation) { e.WithTitle("T").WithInitialDir("/a") }, winfileask.Options{Title: "T"}, "initial directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			m := New(tb)
+			tt.expect(m.ExpectOpen().Return("x"))
+			if _, err := m.Open(tt.opts); err != ErrUnexpected {
+				t.Errorf("err = %v, want ErrUnexpected", err)
+			}
+			tb.finish()
+			checkErrors(t, tb, tt.err, "was not shown")
+		})
+	}
+}
+
+func TestMockUnmet(t *testing.T) {
+	tb := &fakeTB{}
+	m := New(tb)
+	m.ExpectOpen().Return("a")
+	m.ExpectSave()
+	m.ExpectFolder()
+	m.Open(winfileask.Options{})
+	if len(tb.errors) != 0 {
+		t.Fatalf("reported %q before the test ended", tb.errors)
+	}
+	tb.finish()
+	checkErrors(t, tb, "expected Save dialog was not shown", "expected Folder dialog was not shown")
+}
+
+func TestMockInstall(t *testing.T) {
+	tb := &fakeTB{}
+	m := New(tb)
+	m.ExpectSave().Return("/out/report.txt")
+	m.Install()
+	if d := winfileask.Default(); d != m {
+		t.Fatalf("Default() = %v, want the mock", d)
+	}
+	res, err := winfileask.Save(winfileask.Options{})
+	if err != nil || res.Paths[0] != "/out/report.txt" {
+		t.Errorf("Save = %v, %v", res, err)
+	}
+	tb.finish()
+	checkErrors(t, tb)
+	if prev := winfileask.SetDefault(nil); prev != nil {
+		t.Errorf("after the test the default is still %v", prev)
+	}
+}
+
+func TestMockGetFileName(t *testing.T) {
+	tb := &fakeTB{}
+	m := New(tb)
+	filter := winfileask.FileFilter{{Name: "Text", Pattern: "*.txt"}}
+	m.ExpectOpen().WithTitle("Open log").WithFilter(filter).WithInitialDir("/logs").Return("/logs/a.txt")
+	m.ExpectSave().Cancel()
+	m.ExpectOpen().ReturnError(errors.New("no access"))
+	m.Install()
+	path, ok, err := winfileask.GetOpenFileName(nil, "Open log", filter, "/logs")
+	if path != "/logs/a.txt" || !ok || err != nil {
+		t.Errorf("GetOpenFileName = %q, %v, %v; want the path, true, nil", path, ok, err)
+	}
+	if path, ok, err = winfileask.GetSaveFileName(nil, "", nil, ""); path != "" || ok || err != nil {
+		t.Errorf("canceled GetSaveFileName = %q, %v, %v; want \"\", false, nil", path, ok, err)
+	}
+	if _, ok, err = winfileask.GetOpenFileName(nil, "", nil, ""); ok || err == nil || err.Error() != "no access" {
+		t.Errorf("failed GetOpenFileName = %v, %v; want false and the error", ok, err)
+	}
+	tb.finish()
+	checkErrors(t, tb)
+}
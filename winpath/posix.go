@@ -0,0 +1,160 @@
+package winpath
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Style is a POSIX view of the Windows file system.
+type Style int
+
+// The supported POSIX styles.
+const (
+	// WSL mounts drives under /mnt, as in /mnt/c/dir, and exposes its own
+	// file system to Windows as \\wsl.localhost\<distro>.
+	WSL Style = iota
+	// MSYS mounts drives at the root, as in /c/dir.
+	MSYS
+	// Cygwin mounts drives under /cygdrive, as in /cygdrive/c/dir.
+	Cygwin
+)
+
+// Converter translates paths between Windows and a POSIX Style. The zero
+// value translates drive paths for WSL with the default mount root.
+type Converter struct {
+	Style Style
+	// MountRoot is the directory drives are mounted under. If it is empty,
+	// the default of the style is used: "/mnt/", "/" or "/cygdrive/".
+	MountRoot string
+	// Distro is the WSL distribution whose file system is reached through
+	// \\wsl.localhost\<Distro>. If it is empty, Linux paths outside the
+	// drive mounts cannot be translated to Windows, and shares of any
+	// distribution are accepted when translating to POSIX.
+	Distro string
+	// Root is the Windows directory the POSIX root maps to in the MSYS and
+	// Cygwin styles, such as C:\msys64.
+	Root string
+	// CurrentDir is the fully qualified Windows directory that relative,
+	// drive-relative and root-relative Windows paths are resolved against.
+	// If it is empty, relative paths stay relative and the other two kinds
+	// cannot be translated.
+	CurrentDir string
+}
+
+func (c Converter) mountRoot() string {
+	root := c.MountRoot
+	if root == "" {
+		root = [...]string{"/mnt/", "/", "/cygdrive/"}[c.Style]
+	}
+	if !strings.HasSuffix(root, "/") {
+		root += "/"
+	}
+	return root
+}
+
+// ToPOSIX translates a Windows path into the converter's style.
+func (c Converter) ToPOSIX(win string) (string, error) {
+	p, err := Parse(win)
+	if err != nil {
+		return "", err
+	}
+	if p.Kind == Relative && c.CurrentDir == "" {
+		return strings.Join(p.Elems, "/"), nil
+	}
+	if !p.IsAbs() {
+		if c.CurrentDir == "" {
+			return "", fmt.Errorf("winpath: %q: %w", win, ErrNotAbsolute)
+		}
+		var cwd Path
+		if cwd, err = Parse(c.CurrentDir); err != nil {
+			return "", err
+		}
+		if p, err = p.Abs(cwd); err != nil {
+			return "", err
+		}
+	}
+	if c.Style != WSL && c.Root != "" {
+		if root, err := Parse(c.Root); err == nil && root.IsAbs() {
+			if rest, ok := under(p, root); ok {
+				return "/" + strings.Join(rest, "/"), nil
+			}
+		}
+	}
+	switch {
+	case p.Kind == DriveAbsolute:
+		return strings.TrimSuffix(c.mountRoot()+strings.ToLower(string(p.Drive))+"/"+strings.Join(p.Elems, "/"), "/"), nil
+	case c.Style == WSL && p.IsWSLShare() && (c.Distro == "" || strings.EqualFold(p.Share, c.Distro)):
+		return "/" + strings.Join(p.Elems, "/"), nil
+	case c.Style != WSL && !p.IsWSLShare():
+		return strings.TrimSuffix("//"+p.Server+"/"+p.Share+"/"+strings.Join(p.Elems, "/"), "/"), nil
+	}
+	return "", fmt.Errorf("winpath: %q: %w", win, ErrNoMapping)
+}
+
+// ToWindows translates a path in the converter's style into a Windows path.
+func (c Converter) ToWindows(posix string) (string, error) {
+	if posix == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(posix, "/") {
+		p := Path{Kind: Relative}.Join(strings.Split(posix, "/")...)
+		if c.CurrentDir == "" {
+			return p.String(), nil
+		}
+		cwd, err := Parse(c.CurrentDir)
+		if err != nil {
+			return "", err
+		}
+		if p, err = p.Abs(cwd); err != nil {
+			return "", err
+		}
+		return p.String(), nil
+	}
+	if c.Style != WSL && strings.HasPrefix(posix, "//") && !strings.HasPrefix(posix, "///") {
+		p, err := Parse(posix)
+		if err != nil {
+			return "", err
+		}
+		return p.String(), nil
+	}
+	clean := path.Clean(posix)
+	root := c.mountRoot()
+	if rest := strings.TrimPrefix(clean+"/", root); rest != clean+"/" {
+		drive, tail, _ := strings.Cut(rest, "/")
+		if len(drive) == 1 && isLetter(drive[0]) {
+			return Path{Kind: DriveAbsolute, Drive: upper(drive[0])}.Join(strings.Split(tail, "/")...).String(), nil
+		}
+	}
+	elems := strings.Split(clean, "/")
+	switch {
+	case c.Style == WSL && c.Distro != "":
+		return Path{Kind: UNC, Server: "wsl.localhost", Share: c.Distro}.Join(elems...).String(), nil
+	case c.Style != WSL && c.Root != "":
+		root, err := Parse(c.Root)
+		if err != nil {
+			return "", err
+		}
+		if !root.IsAbs() {
+			return "", fmt.Errorf("winpath: root %q: %w", c.Root, ErrNotAbsolute)
+		}
+		return root.Join(elems...).String(), nil
+	}
+	return "", fmt.Errorf("winpath: %q: %w", posix, ErrNoMapping)
+}
+
+// under reports whether p is inside dir, comparing case-insensitively as
+// Windows does, and returns the components of p below dir.
+func under(p, dir Path) ([]string, bool) {
+	if p.Kind != dir.Kind || p.Drive != dir.Drive ||
+		!strings.EqualFold(p.Server, dir.Server) || !strings.EqualFold(p.Share, dir.Share) ||
+		len(p.Elems) < len(dir.Elems) {
+		return nil, false
+	}
+	for i, e := range dir.Elems {
+		if !strings.EqualFold(p.Elems[i], e) {
+			return nil, false
+		}
+	}
+	return p.Elems[len(dir.Elems):], true
+}
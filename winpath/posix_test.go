@@ -0,0 +1,153 @@
+package winpath
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToPOSIX(t *testing.T) {
+	wsl := Converter{Style: WSL, Distro: "Ubuntu"}
+	tests := []struct {
+		name string
+		conv Converter
+		in   string
+		want string
+	}{
+		{"wsl drive", wsl, `C:\Users\u\file.txt`, "/mnt/c/Users/u/file.txt"},
+		{"wsl drive root", wsl, `D:\`, "/mnt/d"},
+		{"wsl slashes", wsl, `c:/Users/u`, "/mnt/c/Users/u"},
+		{"wsl long", wsl, `\\?\C:\deep\path`, "/mnt/c/deep/path"},
+		{"wsl mount root", Converter{MountRoot: "/"}, `C:\x`, "/c/x"},
+		{"wsl mount root without slash", Converter{MountRoot: "/win"}, `C:\x`, "/win/c/x"},
+		{"wsl$ share", wsl, `\\wsl$\Ubuntu\home\u`, "/home/u"},
+		{"wsl.localhost share", wsl, `\\wsl.localhost\Ubuntu\etc\hosts`, "/etc/hosts"},
+		{"share case", wsl, `\\WSL.LOCALHOST\ubuntu\etc`, "/etc"},
+		{"share root", wsl, `\\wsl.localhost\Ubuntu`, "/"},
+		{"long share", wsl, `\\?\UNC\wsl.localhost\Ubuntu\tmp`, "/tmp"},
+		{"any distro", Converter{}, `\\wsl$\Debian\srv`, "/srv"},
+		{"relative", wsl, `dir\file`, "dir/file"},
+		{"relative with cwd", Converter{CurrentDir: `C:\work`}, `dir\file`, "/mnt/c/work/dir/file"},
+		{"root relative with cwd", Converter{CurrentDir: `D:\work`}, `\file`, "/mnt/d/file"},
+		{"drive relative with cwd", Converter{CurrentDir: `C:\work`}, `C:file`, "/mnt/c/work/file"},
+		{"msys drive", Converter{Style: MSYS}, `C:\Users\u`, "/c/Users/u"},
+		{"msys root", Converter{Style: MSYS, Root: `C:\msys64`}, `C:\msys64\usr\bin`, "/usr/bin"},
+		{"msys root case", Converter{Style: MSYS, Root: `c:\MSYS64`}, `C:\msys64\etc`, "/etc"},
+		{"msys outside root", Converter{Style: MSYS, Root: `C:\msys64`}, `C:\Windows`, "/c/Windows"},
+		{"msys unc", Converter{Style: MSYS}, `\\server\share\dir`, "//server/share/dir"},
+		{"msys share root", Converter{Style: MSYS}, `\\server\share`, "//server/share"},
+		{"cygwin drive", Converter{Style: Cygwin}, `E:\data`, "/cygdrive/e/data"},
+		{"cygwin root", Converter{Style: Cygwin, Root: `C:\cygwin64`}, `C:\cygwin64\home\u`, "/home/u"},
+		{"cygwin unc", Converter{Style: Cygwin}, `\\server\share`, "//server/share"},
+	}
+	for _, tt := range tests {
+		got, err := tt.conv.ToPOSIX(tt.in)
+		if err != nil {
+			t.Errorf("%s: ToPOSIX(%q): %v", tt.name, tt.in, err)
+		} else if got != tt.want {
+			t.Errorf("%s: ToPOSIX(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestToPOSIXErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		conv Converter
+		in   string
+		err  error
+	}{
+		{"wsl unc", Converter{Style: WSL}, `\\server\share\x`, ErrNoMapping},
+		{"other distro", Converter{Distro: "Ubuntu"}, `\\wsl$\Debian\x`, ErrNoMapping},
+		{"msys wsl share", Converter{Style: MSYS}, `\\wsl.localhost\Ubuntu\x`, ErrNoMapping},
+		{"root relative", Converter{}, `\dir`, ErrNotAbsolute},
+		{"drive relative", Converter{}, `C:dir`, ErrNotAbsolute},
+		{"bad path", Converter{}, `\\server`, nil},
+	}
+	for _, tt := range tests {
+		got, err := tt.conv.ToPOSIX(tt.in)
+		if err == nil || (tt.err != nil && !errors.Is(err, tt.err)) {
+			t.Errorf("%s: ToPOSIX(%q) = %q, %v; want %v", tt.name, tt.in, got, err, tt.err)
+		}
+	}
+}
+
+func TestToWindows(t *testing.T) {
+	wsl := Converter{Style: WSL, Distro: "Ubuntu"}
+	tests := []struct {
+		name string
+		conv Converter
+		in   string
+		want string
+	}{
+		{"wsl drive", wsl, "/mnt/c/Users/u/file.txt", `C:\Users\u\file.txt`},
+		{"wsl drive root", wsl, "/mnt/d", `D:\`},
+		{"wsl drive root slash", wsl, "/mnt/d/", `D:\`},
+		{"wsl unclean", wsl, "/mnt/c/a/../b/./c", `C:\b\c`},
+		{"wsl linux path", wsl, "/home/u", `\\wsl.localhost\Ubuntu\home\u`},
+		{"wsl root", wsl, "/", `\\wsl.localhost\Ubuntu`},
+		{"wsl mnt", wsl, "/mnt", `\\wsl.localhost\Ubuntu\mnt`},
+		{"wsl mnt not a drive", wsl, "/mnt/data/x", `\\wsl.localhost\Ubuntu\mnt\data\x`},
+		{"wsl mount root", Converter{MountRoot: "/", Distro: "U"}, "/c/x", `C:\x`},
+		{"wsl relative", wsl, "dir/file", `dir\file`},
+		{"wsl relative with cwd", Converter{CurrentDir: `C:\work`}, "../file", `C:\file`},
+		{"empty", wsl, "", ""},
+		{"msys drive", Converter{Style: MSYS}, "/c/Users/u", `C:\Users\u`},
+		{"msys root", Converter{Style: MSYS, Root: `C:\msys64`}, "/usr/bin", `C:\msys64\usr\bin`},
+		{"msys unc", Converter{Style: MSYS}, "//server/share/dir", `\\server\share\dir`},
+		{"cygwin drive", Converter{Style: Cygwin}, "/cygdrive/e/data", `E:\data`},
+		{"cygwin root", Converter{Style: Cygwin, Root: `C:\cygwin64`}, "/home/u", `C:\cygwin64\home\u`},
+		{"cygwin unc", Converter{Style: Cygwin}, "//server/share", `\\server\share`},
+	}
+	for _, tt := range tests {
+		got, err := tt.conv.ToWindows(tt.in)
+		if err != nil {
+			t.Errorf("%s: ToWindows(%q): %v", tt.name, tt.in, err)
+		} else if got != tt.want {
+			t.Errorf("%s: ToWindows(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestToWindowsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		conv Converter
+		in   string
+		err  error
+	}{
+		{"wsl without distro", Converter{}, "/home/u", ErrNoMapping},
+		{"msys without root", Converter{Style: MSYS}, "/usr/bin", ErrNoMapping},
+		{"cygwin relative root", Converter{Style: Cygwin, Root: `cygwin`}, "/home", ErrNotAbsolute},
+		{"wsl unc", Converter{}, "//server/share", ErrNoMapping},
+	}
+	for _, tt := range tests {
+		got, err := tt.conv.ToWindows(tt.in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: ToWindows(%q) = %q, %v; want %v", tt.name, tt.in, got, err, tt.err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		conv  Converter
+		paths []string
+	}{
+		{Converter{Style: WSL, Distro: "Ubuntu"}, []string{`C:\Users\u\a b.txt`, `D:\`, `\\wsl.localhost\Ubuntu\home\u`}},
+		{Converter{Style: MSYS, Root: `C:\msys64`}, []string{`C:\msys64\usr`, `D:\x`, `\\server\share\y`}},
+		{Converter{Style: Cygwin, Root: `C:\cygwin64`}, []string{`C:\cygwin64\etc`, `E:\data`, `\\server\share`}},
+	}
+	for _, tt := range tests {
+		for _, win := range tt.paths {
+			posix, err := tt.conv.ToPOSIX(win)
+			if err != nil {
+				t.Errorf("ToPOSIX(%q): %v", win, err)
+				continue
+			}
+			back, err := tt.conv.ToWindows(posix)
+			if err != nil || back != win {
+				t.Errorf("style %d: %q -> %q -> %q, %v", tt.conv.Style, win, posix, back, err)
+			}
+		}
+	}
+}
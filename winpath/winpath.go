@@ -0,0 +1,208 @@
+// Package winpath translates between Windows paths and the forms the same
+// files take under WSL, MSYS and Cygwin. It is pure Go and does not touch the
+// file system, so it works the same on every platform.
+//
+// Windows paths are accepted with either separator and in drive (C:\dir),
+// drive-relative (C:dir), root-relative (\dir), relative, UNC
+// (\\server\share\dir) and long or device (\\?\C:\dir, \\?\UNC\server\share,
+// \\.\C:\dir) forms. The shares \\wsl$\<distro> and \\wsl.localhost\<distro>
+// are the Linux file systems of WSL distributions.
+package winpath
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kind is the form of a Windows path.
+type Kind int
+
+// The kinds of Windows paths.
+const (
+	// Relative is relative to the current directory, like dir\file.
+	Relative Kind = iota
+	// DriveRelative is relative to the current directory of a drive, like
+	// C:dir\file.
+	DriveRelative
+	// RootRelative is relative to the root of the current drive, like
+	// \dir\file.
+	RootRelative
+	// DriveAbsolute is a fully qualified drive path, like C:\dir\file.
+	DriveAbsolute
+	// UNC is a fully qualified network path, like \\server\share\dir\file.
+	UNC
+)
+
+// Path is a parsed Windows path.
+type Path struct {
+	Kind Kind
+	// Drive is the upper-case drive letter of DriveRelative and
+	// DriveAbsolute paths.
+	Drive byte
+	// Server and Share name the share of a UNC path.
+	Server, Share string
+	// Elems are the cleaned components following the drive, share or root.
+	// Only relative kinds can start with "..".
+	Elems []string
+}
+
+// Errors returned by Parse and the conversions.
+var (
+	ErrNotAbsolute = errors.New("path is not absolute")
+	ErrNoMapping   = errors.New("path has no equivalent in the target form")
+)
+
+// Parse parses a Windows path, accepting both \ and / as separators. The long
+// and device prefixes \\?\ and \\.\ are removed.
+func Parse(p string) (Path, error) {
+	s := strings.ReplaceAll(p, "/", `\`)
+	if strings.HasPrefix(s, `\\?\`) || strings.HasPrefix(s, `\\.\`) {
+		s = s[4:]
+		switch {
+		case len(s) >= 4 && strings.EqualFold(s[:4], `UNC\`):
+			s = `\\` + s[4:]
+		case len(s) >= 2 && isLetter(s[0]) && s[1] == ':':
+			if len(s) == 2 {
+				s += `\`
+			}
+		default:
+			return Path{}, fmt.Errorf("winpath: unsupported device path %q", p)
+		}
+	}
+	var path Path
+	switch {
+	case strings.HasPrefix(s, `\\`):
+		parts := strings.SplitN(s[2:], `\`, 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return Path{}, fmt.Errorf("winpath: UNC path %q has no share", p)
+		}
+		path.Kind, path.Server, path.Share = UNC, parts[0], parts[1]
+		if len(parts) == 3 {
+			s = parts[2]
+		} else {
+			s = ""
+		}
+	case len(s) >= 2 && isLetter(s[0]) && s[1] == ':':
+		path.Drive = upper(s[0])
+		s = s[2:]
+		path.Kind = DriveRelative
+		if strings.HasPrefix(s, `\`) {
+			path.Kind = DriveAbsolute
+		}
+	case strings.HasPrefix(s, `\`):
+		path.Kind = RootRelative
+	}
+	path.Elems = clean(strings.Split(s, `\`), path.Kind == Relative || path.Kind == DriveRelative)
+	return path, nil
+}
+
+// MustParse is like Parse but panics if the path cannot be parsed.
+func MustParse(p string) Path {
+	path, err := Parse(p)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// IsAbs reports whether the path is fully qualified.
+func (p Path) IsAbs() bool {
+	return p.Kind == DriveAbsolute || p.Kind == UNC
+}
+
+// String returns the path in its usual Windows form.
+func (p Path) String() string {
+	rest := strings.Join(p.Elems, `\`)
+	switch p.Kind {
+	case DriveRelative:
+		return string(p.Drive) + ":" + rest
+	case RootRelative:
+		return `\` + rest
+	case DriveAbsolute:
+		return string(p.Drive) + `:\` + rest
+	case UNC:
+		if rest == "" {
+			return `\\` + p.Server + `\` + p.Share
+		}
+		return `\\` + p.Server + `\` + p.Share + `\` + rest
+	}
+	return rest
+}
+
+// Long returns the path in the \\?\ form, which is exempt from the MAX_PATH
+// limit. Paths that are not fully qualified are returned as by String.
+func (p Path) Long() string {
+	switch p.Kind {
+	case DriveAbsolute:
+		return `\\?\` + p.String()
+	case UNC:
+		return `\\?\UNC\` + p.String()[2:]
+	}
+	return p.String()
+}
+
+// Join returns p with elems appended and cleaned.
+func (p Path) Join(elems ...string) Path {
+	all := append(append([]string(nil), p.Elems...), elems...)
+	p.Elems = clean(all, p.Kind == Relative || p.Kind == DriveRelative)
+	return p
+}
+
+// Abs resolves p against the fully qualified directory cwd the way Windows
+// does. A drive-relative path on a drive other than that of cwd is resolved
+// against the root of its drive, since the current directories of other
+// drives are not known.
+func (p Path) Abs(cwd Path) (Path, error) {
+	if !cwd.IsAbs() {
+		return Path{}, ErrNotAbsolute
+	}
+	switch p.Kind {
+	case Relative:
+		return cwd.Join(p.Elems...), nil
+	case RootRelative:
+		cwd.Elems = nil
+		return cwd.Join(p.Elems...), nil
+	case DriveRelative:
+		if cwd.Kind == DriveAbsolute && cwd.Drive == p.Drive {
+			return cwd.Join(p.Elems...), nil
+		}
+		return Path{Kind: DriveAbsolute, Drive: p.Drive}.Join(p.Elems...), nil
+	}
+	return p, nil
+}
+
+// IsWSLShare reports whether p is on the \\wsl$ or \\wsl.localhost share of
+// a WSL distribution.
+func (p Path) IsWSLShare() bool {
+	return p.Kind == UNC && (strings.EqualFold(p.Server, "wsl$") || strings.EqualFold(p.Server, "wsl.localhost"))
+}
+
+// clean removes empty and "." components and resolves "..". If relative is
+// set, leading ".." components are kept; otherwise they are dropped as they
+// would go above the root.
+func clean(elems []string, relative bool) []string {
+	out := []string{}
+	for _, e := range elems {
+		switch {
+		case e == "" || e == ".":
+		case e == ".." && len(out) > 0 && out[len(out)-1] != "..":
+			out = out[:len(out)-1]
+		case e == ".." && !relative:
+		default:
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func upper(c byte) byte {
+	if 'a' <= c && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
@@ -0,0 +1,186 @@
+package winpath
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Path
+		str  string
+	}{
+		{`C:\dir\file.txt`, Path{Kind: DriveAbsolute, Drive: 'C', Elems: []string{"dir", "file.txt"}}, `C:\dir\file.txt`},
+		{`c:/dir/file.txt`, Path{Kind: DriveAbsolute, Drive: 'C', Elems: []string{"dir", "file.txt"}}, `C:\dir\file.txt`},
+		{`C:\`, Path{Kind: DriveAbsolute, Drive: 'C', Elems: []string{}}, `C:\`},
+		{`C:\a\.\b\..\c\\d\`, Path{Kind: DriveAbsolute, Drive: 'C', Elems: []string{"a", "c", "d"}}, `C:\a\c\d`},
+		{`C:\..\a`, Path{Kind: DriveAbsolute, Drive: 'C', Elems: []string{"a"}}, `C:\a`},
+		{`d:dir\file`, Path{Kind: DriveRelative, Drive: 'D', Elems: []string{"dir", "file"}}, `D:dir\file`},
+		{`D:`, Path{Kind: DriveRelative, Drive: 'D', Elems: []string{}}, `D:`},
+		{`D:..\x`, Path{Kind: DriveRelative, Drive: 'D', Elems: []string{"..", "x"}}, `D:..\x`},
+		{`\dir\file`, Path{Kind: RootRelative, Elems: []string{"dir", "file"}}, `\dir\file`},
+		{`\..\dir`, Path{Kind: RootRelative, Elems: []string{"dir"}}, `\dir`},
+		{`dir\file`, Path{Kind: Relative, Elems: []string{"dir", "file"}}, `dir\file`},
+		{`..\..\file`, Path{Kind: Relative, Elems: []string{"..", "..", "file"}}, `..\..\file`},
+		{`a\..\..\b`, Path{Kind: Relative, Elems: []string{"..", "b"}}, `..\b`},
+		{``, Path{Kind: Relative, Elems: []string{}}, ``},
+		{`\\server\share\dir\file`, Path{Kind: UNC, Server: "server", Share: "share", Elems: []string{"dir", "file"}}, `\\server\share\dir\file`},
+		{`\\server\share`, Path{Kind: UNC, Server: "server", Share: "share", Elems: []string{}}, `\\server\share`},
+		{`//server/share/`, Path{Kind: UNC, Server: "server", Share: "share", Elems: []string{}}, `\\server\share`},
+		{`\\server\share\..\x`, Path{Kind: UNC, Server: "server", Share: "share", Elems: []string{"x"}}, `\\server\share\x`},
+		{`\\?\C:\very\long`, Path{Kind: DriveAbsolute, Drive: 'C', Elems: []string{"very", "long"}}, `C:\very\long`},
+		{`\\?\c:`, Path{Kind: DriveAbsolute, Drive: 'C', Elems: []string{}}, `C:\`},
+		{`\\?\UNC\server\share\dir`, Path{Kind: UNC, Server: "server", Share: "share", Elems: []string{"dir"}}, `\\server\share\dir`},
+		{`\\?\unc\server\share`, Path{Kind: UNC, Server: "server", Share: "share", Elems: []string{}}, `\\server\share`},
+		{`\\.\C:\dir`, Path{Kind: DriveAbsolute, Drive: 'C', Elems: []string{"dir"}}, `C:\dir`},
+		{`\\.\UNC\server\share\f`, Path{Kind: UNC, Server: "server", Share: "share", Elems: []string{"f"}}, `\\server\share\f`},
+		{`\\wsl$\Ubuntu\home\u`, Path{Kind: UNC, Server: "wsl$", Share: "Ubuntu", Elems: []string{"home", "u"}}, `\\wsl$\Ubuntu\home\u`},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if s := got.String(); s != tt.str {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, s, tt.str)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		`\\server`,
+		`\\server\`,
+		`\\\share`,
+		`\\?\Volume{b75e2c83-0000-0000-0000-602f00000000}\dir`,
+		`\\.\PhysicalDrive0`,
+		`\\?\UNC\server`,
+		`\\.\`,
+	} {
+		if p, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", in, p)
+		}
+	}
+}
+
+func TestIsAbs(t *testing.T) {
+	tests := map[string]bool{
+		`C:\`:       true,
+		`\\s\share`: true,
+		`\\?\C:\x`:  true,
+		`C:x`:       false,
+		`\x`:        false,
+		`x`:         false,
+	}
+	for in, want := range tests {
+		if got := MustParse(in).IsAbs(); got != want {
+			t.Errorf("IsAbs(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestLong(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`C:\dir\file`, `\\?\C:\dir\file`},
+		{`C:\`, `\\?\C:\`},
+		{`\\server\share\dir`, `\\?\UNC\server\share\dir`},
+		{`\\server\share`, `\\?\UNC\server\share`},
+		{`\\?\D:\x`, `\\?\D:\x`},
+		{`C:dir`, `C:dir`},
+		{`\dir`, `\dir`},
+		{`dir\file`, `dir\file`},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.in).Long(); got != tt.want {
+			t.Errorf("Long(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAbs(t *testing.T) {
+	tests := []struct {
+		in, cwd, want string
+	}{
+		{`file`, `C:\work`, `C:\work\file`},
+		{`..\file`, `C:\work\sub`, `C:\work\file`},
+		{`..\..\..\file`, `C:\work`, `C:\file`},
+		{`\file`, `C:\work\sub`, `C:\file`},
+		{`\file`, `\\server\share\dir`, `\\server\share\file`},
+		{`file`, `\\server\share\dir`, `\\server\share\dir\file`},
+		{`C:file`, `C:\work`, `C:\work\file`},
+		{`c:file`, `C:\work`, `C:\work\file`},
+		{`D:file`, `C:\work`, `D:\file`},
+		{`D:..\file`, `C:\work`, `D:\file`},
+		{`D:file`, `\\server\share`, `D:\file`},
+		{`E:\abs`, `C:\work`, `E:\abs`},
+		{`\\s\sh\x`, `C:\work`, `\\s\sh\x`},
+	}
+	for _, tt := range tests {
+		got, err := MustParse(tt.in).Abs(MustParse(tt.cwd))
+		if err != nil {
+			t.Errorf("Abs(%q, %q): %v", tt.in, tt.cwd, err)
+			continue
+		}
+		if s := got.String(); s != tt.want {
+			t.Errorf("Abs(%q, %q) = %q, want %q", tt.in, tt.cwd, s, tt.want)
+		}
+	}
+	for _, cwd := range []string{`work`, `\work`, `C:work`} {
+		if _, err := MustParse(`file`).Abs(MustParse(cwd)); !errors.Is(err, ErrNotAbsolute) {
+			t.Errorf("Abs against %q: err = %v, want ErrNotAbsolute", cwd, err)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		base  string
+		elems []string
+		want  string
+	}{
+		{`C:\a`, []string{"b", "c"}, `C:\a\b\c`},
+		{`C:\a`, []string{"..", "..", "b"}, `C:\b`},
+		{`a`, []string{"..", "..", "b"}, `..\b`},
+		{`\\s\sh`, []string{".", "x"}, `\\s\sh\x`},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.base).Join(tt.elems...).String(); got != tt.want {
+			t.Errorf("Join(%q, %q) = %q, want %q", tt.base, tt.elems, got, tt.want)
+		}
+	}
+}
+
+func TestIsWSLShare(t *testing.T) {
+	tests := map[string]bool{
+		`\\wsl$\Ubuntu\home`:         true,
+		`\\WSL$\Ubuntu`:              true,
+		`\\wsl.localhost\Debian\etc`: true,
+		`\\WSL.LOCALHOST\Debian`:     true,
+		`\\?\UNC\wsl.localhost\D\x`:  true,
+		`\\server\share`:             false,
+		`\\wsl.example.com\Ubuntu\x`: false,
+		`C:\wsl$\Ubuntu`:             false,
+	}
+	for in, want := range tests {
+		if got := MustParse(in).IsWSLShare(); got != want {
+			t.Errorf("IsWSLShare(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestMustParsePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustParse of an invalid path did not panic")
+		}
+	}()
+	MustParse(`\\server`)
+}
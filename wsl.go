@@ -0,0 +1,210 @@
+package winfileask
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/kroppt/winfileask/winpath"
+)
+
+// HelperEnv is the environment variable naming the winfileask-helper
+// executable used by the WSL backend when its Helper member is empty.
+const HelperEnv = "WINFILEASK_HELPER"
+
+// WSL is a Backend that shows the native Windows dialogs from inside the
+// Windows Subsystem for Linux. It runs a Windows helper process through WSL
+// interop, passing the Options as JSON on its standard input, and translates
+// paths between their Linux and Windows forms.
+//
+// The helper is either a winfileask-helper.exe built from
+// cmd/winfileask-helper, or powershell.exe running an embedded script that
+// uses the Windows Forms dialogs.
+type WSL struct {
+	// Helper is the path of winfileask-helper.exe. If it is empty, the value
+	// of HelperEnv is used, and if that is empty too, powershell.exe.
+	Helper string
+	// MountRoot is the directory Windows drives are mounted under. If it is
+	// empty, it is read from /etc/wsl.conf and defaults to "/mnt/".
+	MountRoot string
+	// Distro is the name of the distribution, used to reach Linux paths from
+	// Windows. If it is empty, WSL_DISTRO_NAME is used.
+	Distro string
+}
+
+// IsWSL reports whether the program runs inside the Windows Subsystem for
+// Linux with interop enabled.
+func IsWSL() bool {
+	if _, err := os.Stat("/proc/sys/fs/binfmt_misc/WSLInterop"); err == nil {
+		return true
+	}
+	return os.Getenv("WSL_INTEROP") != "" || os.Getenv("WSL_DISTRO_NAME") != ""
+}
+
+// Show implements Backend.
+func (w *WSL) Show(opts Options) (*Result, error) {
+	conv := winpath.Converter{Style: winpath.WSL, MountRoot: w.mountRoot(), Distro: w.distro()}
+	req := opts
+	req.InitialDir = ""
+	if dir, err := filepath.Abs(opts.InitialDir); err == nil && opts.InitialDir != "" {
+		// The initial directory is only a hint, so drop it if Windows
+		// cannot reach it.
+		req.InitialDir, _ = conv.ToWindows(dir)
+	}
+	// The helper checks the picks against the roots as Windows paths. Roots
+	// Windows cannot reach are dropped; the selection is still checked
+	// against every root once it is converted back.
+	req.AllowedRoots = nil
+	for _, root := range opts.AllowedRoots {
+		var dir string
+		var err error
+		if dir, err = filepath.Abs(root); err != nil {
+			continue
+		}
+		if dir, err = conv.ToWindows(dir); err == nil {
+			req.AllowedRoots = append(req.AllowedRoots, dir)
+		}
+	}
+	data, err := marshalASCII(req)
+	if err != nil {
+		return nil, err
+	}
+	var cmd *exec.Cmd
+	helper := w.Helper
+	if helper == "" {
+		helper = os.Getenv(HelperEnv)
+	}
+	if helper != "" {
+		cmd = exec.Command(helper)
+	} else {
+		cmd = exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-STA",
+			"-EncodedCommand", encodePowerShell(wslScript))
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%s: %v: %s", cmd.Path, err, strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	var resp helperResponse
+	if err = json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("%s: invalid response: %v", cmd.Path, err)
+	}
+	switch {
+	case resp.Error != "":
+		return nil, errors.New(resp.Error)
+	case resp.Canceled || len(resp.Paths) == 0:
+		return nil, ErrCanceled
+	}
+	res := &Result{FilterIndex: resp.FilterIndex}
+	for _, p := range resp.Paths {
+		if p, err = conv.ToPOSIX(p); err != nil {
+			return nil, err
+		}
+		res.Paths = append(res.Paths, p)
+	}
+	return res, nil
+}
+
+func (w *WSL) mountRoot() string {
+	if w.MountRoot != "" {
+		return w.MountRoot
+	}
+	return wslMountRoot("/etc/wsl.conf")
+}
+
+func (w *WSL) distro() string {
+	if w.Distro != "" {
+		return w.Distro
+	}
+	return os.Getenv("WSL_DISTRO_NAME")
+}
+
+// wslMountRoot reads the root setting of the automount section of the
+// wsl.conf file at name.
+func wslMountRoot(name string) string {
+	root := "/mnt/"
+	f, err := os.Open(name)
+	if err != nil {
+		return root
+	}
+	defer f.Close()
+	var section string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && section == "automount" && strings.TrimSpace(key) == "root" {
+			root = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return root
+}
+
+// encodePowerShell encodes a script for the -EncodedCommand argument.
+func encodePowerShell(script string) string {
+	u := utf16.Encode([]rune(script))
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		b[2*i] = byte(c)
+		b[2*i+1] = byte(c >> 8)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// wslScript is the PowerShell helper. It speaks the same protocol as
+// ServeHelper using the Windows Forms dialogs.
+const wslScript = `
+$ErrorActionPreference = 'Stop'
+[Console]::OutputEncoding = [Text.Encoding]::UTF8
+Add-Type -AssemblyName System.Windows.Forms
+try {
+	$o = [Console]::In.ReadToEnd() | ConvertFrom-Json
+	if ($o.Mode -eq 2) {
+		$d = New-Object System.Windows.Forms.FolderBrowserDialog
+		$d.Description = $o.Title
+		$d.SelectedPath = $o.InitialDir
+		if ($d.ShowDialog() -ne 'OK') { @{Canceled = $true} | ConvertTo-Json -Compress; exit }
+		@{Paths = @($d.SelectedPath); FilterIndex = -1} | ConvertTo-Json -Compress
+		exit
+	}
+	if ($o.Mode -eq 1) {
+		$d = New-Object System.Windows.Forms.SaveFileDialog
+		$d.OverwritePrompt = ($o.Flags -band 0x2) -ne 0
+		$d.CreatePrompt = ($o.Flags -band 0x2000) -ne 0
+	} else {
+		$d = New-Object System.Windows.Forms.OpenFileDialog
+		$d.Multiselect = ($o.Flags -band 0x200) -ne 0
+		$d.CheckFileExists = ($o.Flags -band 0x1000) -ne 0
+	}
+	$d.CheckPathExists = ($o.Flags -band 0x1800) -ne 0
+	$d.DereferenceLinks = ($o.Flags -band 0x100000) -eq 0
+	$d.Title = $o.Title
+	$d.InitialDirectory = $o.InitialDir
+	$d.FileName = $o.InitialFileName
+	if ($o.Filter) { $d.Filter = ($o.Filter | ForEach-Object { $_.Name + '|' + $_.Pattern }) -join '|' }
+	if ($d.ShowDialog() -ne 'OK') { @{Canceled = $true} | ConvertTo-Json -Compress; exit }
+	$i = -1
+	if ($o.Filter) { $i = $d.FilterIndex - 1 }
+	@{Paths = @($d.FileNames); FilterIndex = $i} | ConvertTo-Json -Compress
+} catch {
+	@{Error = $_.Exception.Message} | ConvertTo-Json -Compress
+}
+`
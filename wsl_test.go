@@ -0,0 +1,224 @@
+package winfileask
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// backendFunc is a Backend that calls itself.
+type backendFunc func(opts Options) (*Result, error)
+
+func (f backendFunc) Show(opts Options) (*Result, error) {
+	return f(opts)
+}
+
+// fakeHelperEnv makes the test binary act as winfileask-helper.exe. Its
+// value selects the answer, and the options received are written to the
+// file named by fakeHelperOptsEnv.
+const (
+	fakeHelperEnv     = "WINFILEASK_TEST_HELPER"
+	fakeHelperOptsEnv = "WINFILEASK_TEST_HELPER_OPTS"
+)
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(fakeHelperEnv); mode != "" {
+		fakeHelper(mode)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// fakeHelper serves a single request on standard input and output.
+func fakeHelper(mode string) {
+	switch mode {
+	case "garbage":
+		fmt.Println("not json")
+		return
+	case "crash":
+		fmt.Fprintln(os.Stderr, "helper crashed")
+		os.Exit(3)
+	}
+	err := ServeHelper(os.Stdin, os.Stdout, backendFunc(func(opts Options) (*Result, error) {
+		if data, err := json.Marshal(opts); err == nil {
+			os.WriteFile(os.Getenv(fakeHelperOptsEnv), data, 0o644)
+		}
+		switch mode {
+		case "cancel":
+			return nil, ErrCanceled
+		case "error":
+			return nil, errors.New("access is denied")
+		case "empty":
+			return &Result{FilterIndex: -1}, nil
+		case "unc":
+			return &Result{Paths: []string{`\\server\share\c.txt`}, FilterIndex: -1}, nil
+		}
+		res := &Result{
+			Paths:       []string{`C:\Users\Zoë\a.txt`, `\\wsl.localhost\Ubuntu\home\u\b.txt`},
+			FilterIndex: 1,
+		}
+		// Refuse the picks outside the roots, as the dialog of the real
+		// helper does.
+		for _, path := range res.Paths {
+			if !insideWindowsRoot(opts.AllowedRoots, path) {
+				return nil, fmt.Errorf("%s is outside the allowed folders", path)
+			}
+		}
+		return res, nil
+	}))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// insideWindowsRoot reports whether the Windows path is inside one of roots,
+// or whether there are none.
+func insideWindowsRoot(roots []string, path string) bool {
+	if len(roots) == 0 {
+		return true
+	}
+	for _, root := range roots {
+		if prefix := strings.TrimSuffix(root, `\`) + `\`; len(path) >= len(prefix) && strings.EqualFold(path[:len(prefix)], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// runFakeHelper shows a dialog through a WSL backend whose helper answers as
+// mode says, and returns the result and the options the helper received.
+func runFakeHelper(t *testing.T, mode string, opts Options) (*Result, Options, error) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("WSL paths are POSIX paths")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	optsFile := filepath.Join(t.TempDir(), "opts.json")
+	t.Setenv(fakeHelperEnv, mode)
+	t.Setenv(fakeHelperOptsEnv, optsFile)
+	w := &WSL{Helper: exe, MountRoot: "/mnt/", Distro: "Ubuntu"}
+	res, err := w.Show(opts)
+	var got Options
+	if data, err := os.ReadFile(optsFile); err == nil {
+		if err = json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return res, got, err
+}
+
+func TestWSLRoundTrip(t *testing.T) {
+	opts := Options{
+		Mode:            ModeOpen,
+		Title:           "Öffnen ✓",
+		Filter:          FileFilter{{Name: "Text", Pattern: "*.txt"}, {Name: "Bilder", Pattern: "*.png"}},
+		InitialDir:      "/mnt/c/Users/Zoë",
+		InitialFileName: "ä.txt",
+		Flags:           OpenFlags | AllowMultiSelect,
+	}
+	res, got, err := runFakeHelper(t, "paths", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/mnt/c/Users/Zoë/a.txt", "/home/u/b.txt"}
+	if !reflect.DeepEqual(res.Paths, want) || res.FilterIndex != 1 {
+		t.Errorf("result = %q, %d; want %q, 1", res.Paths, res.FilterIndex, want)
+	}
+	opts.InitialDir = `C:\Users\Zoë`
+	if !reflect.DeepEqual(got, opts) {
+		t.Errorf("helper received %+v, want %+v", got, opts)
+	}
+}
+
+func TestWSLInitialDir(t *testing.T) {
+	tests := []struct {
+		dir, want string
+	}{
+		{"/mnt/d", `D:\`},
+		{"/mnt/c/Program Files", `C:\Program Files`},
+		{"/home/u", `\\wsl.localhost\Ubuntu\home\u`},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		_, got, err := runFakeHelper(t, "cancel", Options{InitialDir: tt.dir})
+		if err != ErrCanceled {
+			t.Fatalf("err = %v, want ErrCanceled", err)
+		}
+		if got.InitialDir != tt.want {
+			t.Errorf("initial directory %q became %q, want %q", tt.dir, got.InitialDir, tt.want)
+		}
+	}
+}
+
+func TestWSLAllowedRoots(t *testing.T) {
+	res, got, err := runFakeHelper(t, "paths", Options{AllowedRoots: []string{"/mnt/c/Users", "/home/u/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/mnt/c/Users/Zoë/a.txt", "/home/u/b.txt"}; !reflect.DeepEqual(res.Paths, want) {
+		t.Errorf("paths = %q, want %q", res.Paths, want)
+	}
+	if want := []string{`C:\Users`, `\\wsl.localhost\Ubuntu\home\u`}; !reflect.DeepEqual(got.AllowedRoots, want) {
+		t.Errorf("helper received roots %q, want %q", got.AllowedRoots, want)
+	}
+	if _, _, err = runFakeHelper(t, "paths", Options{AllowedRoots: []string{"/mnt/d"}}); err == nil {
+		t.Error("a selection outside the roots was not refused")
+	}
+}
+
+func TestWSLResponses(t *testing.T) {
+	tests := []struct {
+		mode string
+		err  string
+	}{
+		{"cancel", ""},
+		{"empty", ""},
+		{"error", "access is denied"},
+		{"garbage", "invalid response"},
+		{"crash", "helper crashed"},
+		{"unc", "no equivalent"},
+	}
+	for _, tt := range tests {
+		_, _, err := runFakeHelper(t, tt.mode, Options{})
+		switch {
+		case tt.err == "" && err != ErrCanceled:
+			t.Errorf("%s: err = %v, want ErrCanceled", tt.mode, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: err = %v, want %q", tt.mode, err, tt.err)
+		}
+	}
+}
+
+func TestWSLMountRoot(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		conf, want string
+	}{
+		{"", "/mnt/"},
+		{"[automount]\nroot = /\n", "/"},
+		{"[boot]\nroot = /x/\n[automount]\nenabled = true\nroot = \"/win/\"\n", "/win/"},
+		{"[network]\nroot = /x/\n", "/mnt/"},
+	}
+	for i, tt := range tests {
+		name := filepath.Join(dir, fmt.Sprint(i))
+		if err := os.WriteFile(name, []byte(tt.conf), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got := wslMountRoot(name); got != tt.want {
+			t.Errorf("wsl.conf %q: root = %q, want %q", tt.conf, got, tt.want)
+		}
+	}
+	if got := wslMountRoot(filepath.Join(dir, "missing")); got != "/mnt/" {
+		t.Errorf("missing wsl.conf: root = %q, want /mnt/", got)
+	}
+}